@@ -0,0 +1,317 @@
+package eventstream
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ValueType is the wire type of a header value.
+type ValueType uint8
+
+// Header value types.
+const (
+	TrueValueType ValueType = iota
+	FalseValueType
+	Int8ValueType
+	Int16ValueType
+	Int32ValueType
+	Int64ValueType
+	BytesValueType
+	StringValueType
+	TimestampValueType
+	UUIDValueType
+)
+
+const (
+	// maxHeaderNameLen is the longest header name, limited by its one
+	// byte length prefix.
+	maxHeaderNameLen = 255
+
+	// maxHeaderValueLen is the longest bytes or string header value.
+	maxHeaderValueLen = 1<<15 - 1
+)
+
+// Value is a typed header value.
+type Value interface {
+	Type() ValueType
+	String() string
+	encode(w io.Writer) error
+}
+
+// BoolValue is a boolean header value, encoded in its type alone.
+type BoolValue bool
+
+// Type returns TrueValueType or FalseValueType.
+func (v BoolValue) Type() ValueType {
+	if v {
+		return TrueValueType
+	}
+	return FalseValueType
+}
+
+func (v BoolValue) String() string { return strconv.FormatBool(bool(v)) }
+
+func (v BoolValue) encode(w io.Writer) error { return writeType(w, v.Type()) }
+
+// Int8Value is an 8-bit integer header value.
+type Int8Value int8
+
+// Type returns Int8ValueType.
+func (v Int8Value) Type() ValueType { return Int8ValueType }
+
+func (v Int8Value) String() string { return strconv.Itoa(int(v)) }
+
+func (v Int8Value) encode(w io.Writer) error {
+	_, err := w.Write([]byte{byte(Int8ValueType), byte(v)})
+	return err
+}
+
+// Int16Value is a 16-bit integer header value.
+type Int16Value int16
+
+// Type returns Int16ValueType.
+func (v Int16Value) Type() ValueType { return Int16ValueType }
+
+func (v Int16Value) String() string { return strconv.Itoa(int(v)) }
+
+func (v Int16Value) encode(w io.Writer) error {
+	b := []byte{byte(Int16ValueType), 0, 0}
+	binary.BigEndian.PutUint16(b[1:], uint16(v))
+	_, err := w.Write(b)
+	return err
+}
+
+// Int32Value is a 32-bit integer header value.
+type Int32Value int32
+
+// Type returns Int32ValueType.
+func (v Int32Value) Type() ValueType { return Int32ValueType }
+
+func (v Int32Value) String() string { return strconv.Itoa(int(v)) }
+
+func (v Int32Value) encode(w io.Writer) error {
+	b := make([]byte, 5)
+	b[0] = byte(Int32ValueType)
+	binary.BigEndian.PutUint32(b[1:], uint32(v))
+	_, err := w.Write(b)
+	return err
+}
+
+// Int64Value is a 64-bit integer header value.
+type Int64Value int64
+
+// Type returns Int64ValueType.
+func (v Int64Value) Type() ValueType { return Int64ValueType }
+
+func (v Int64Value) String() string { return strconv.FormatInt(int64(v), 10) }
+
+func (v Int64Value) encode(w io.Writer) error {
+	return writeInt64(w, Int64ValueType, int64(v))
+}
+
+// BytesValue is a byte array header value of at most 32767 bytes.
+type BytesValue []byte
+
+// Type returns BytesValueType.
+func (v BytesValue) Type() ValueType { return BytesValueType }
+
+func (v BytesValue) String() string { return hex.EncodeToString(v) }
+
+func (v BytesValue) encode(w io.Writer) error {
+	return writeBytes(w, BytesValueType, v)
+}
+
+// StringValue is a UTF-8 string header value of at most 32767 bytes.
+type StringValue string
+
+// Type returns StringValueType.
+func (v StringValue) Type() ValueType { return StringValueType }
+
+func (v StringValue) String() string { return string(v) }
+
+func (v StringValue) encode(w io.Writer) error {
+	return writeBytes(w, StringValueType, []byte(v))
+}
+
+// TimestampValue is a timestamp header value, encoded as milliseconds
+// since the Unix epoch.
+type TimestampValue time.Time
+
+// Type returns TimestampValueType.
+func (v TimestampValue) Type() ValueType { return TimestampValueType }
+
+func (v TimestampValue) String() string {
+	return time.Time(v).UTC().Format(time.RFC3339Nano)
+}
+
+func (v TimestampValue) encode(w io.Writer) error {
+	return writeInt64(w, TimestampValueType, time.Time(v).UnixMilli())
+}
+
+// UUIDValue is a 16 byte UUID header value.
+type UUIDValue [16]byte
+
+// Type returns UUIDValueType.
+func (v UUIDValue) Type() ValueType { return UUIDValueType }
+
+func (v UUIDValue) String() string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", v[0:4], v[4:6], v[6:8], v[8:10], v[10:])
+}
+
+func (v UUIDValue) encode(w io.Writer) error {
+	if err := writeType(w, UUIDValueType); err != nil {
+		return err
+	}
+	_, err := w.Write(v[:])
+	return err
+}
+
+func writeType(w io.Writer, t ValueType) error {
+	_, err := w.Write([]byte{byte(t)})
+	return err
+}
+
+func writeInt64(w io.Writer, t ValueType, v int64) error {
+	b := make([]byte, 9)
+	b[0] = byte(t)
+	binary.BigEndian.PutUint64(b[1:], uint64(v))
+	_, err := w.Write(b)
+	return err
+}
+
+func writeBytes(w io.Writer, t ValueType, v []byte) error {
+	if len(v) > maxHeaderValueLen {
+		return fmt.Errorf("header value length %d exceeds %d", len(v), maxHeaderValueLen)
+	}
+	b := make([]byte, 3, 3+len(v))
+	b[0] = byte(t)
+	binary.BigEndian.PutUint16(b[1:], uint16(len(v)))
+	_, err := w.Write(append(b, v...))
+	return err
+}
+
+// Header is a named message header.
+type Header struct {
+	Name  string
+	Value Value
+}
+
+// Headers are the headers of a message, in wire order.
+type Headers []Header
+
+// Get returns the value of the first header named name, or nil.
+func (hs Headers) Get(name string) Value {
+	for _, h := range hs {
+		if h.Name == name {
+			return h.Value
+		}
+	}
+	return nil
+}
+
+// Set replaces any headers named name with a single header.
+func (hs *Headers) Set(name string, value Value) {
+	hs.Del(name)
+	*hs = append(*hs, Header{Name: name, Value: value})
+}
+
+// Del removes all headers named name.
+func (hs *Headers) Del(name string) {
+	kept := (*hs)[:0]
+	for _, h := range *hs {
+		if h.Name != name {
+			kept = append(kept, h)
+		}
+	}
+	*hs = kept
+}
+
+// EncodeHeaders writes the wire encoding of hs to w.
+func EncodeHeaders(w io.Writer, hs Headers) error {
+	for _, h := range hs {
+		if len(h.Name) == 0 || len(h.Name) > maxHeaderNameLen {
+			return fmt.Errorf("invalid header name length %d", len(h.Name))
+		}
+		if h.Value == nil {
+			return fmt.Errorf("header %q has no value", h.Name)
+		}
+		if _, err := w.Write(append([]byte{byte(len(h.Name))}, h.Name...)); err != nil {
+			return err
+		}
+		if err := h.Value.encode(w); err != nil {
+			return fmt.Errorf("header %q: %w", h.Name, err)
+		}
+	}
+	return nil
+}
+
+// decodeHeaders parses the headers section of a message.
+func decodeHeaders(b []byte) (Headers, error) {
+	var hs Headers
+	for len(b) > 0 {
+		n := int(b[0])
+		if n == 0 || len(b) < 2+n {
+			return nil, fmt.Errorf("truncated header name")
+		}
+		name := string(b[1 : 1+n])
+		t := ValueType(b[1+n])
+		b = b[2+n:]
+
+		var (
+			v    Value
+			size int
+		)
+		switch t {
+		case TrueValueType, FalseValueType:
+			v = BoolValue(t == TrueValueType)
+		case Int8ValueType:
+			size = 1
+		case Int16ValueType:
+			size = 2
+		case Int32ValueType:
+			size = 4
+		case Int64ValueType, TimestampValueType:
+			size = 8
+		case UUIDValueType:
+			size = 16
+		case BytesValueType, StringValueType:
+			if len(b) < 2 {
+				return nil, fmt.Errorf("truncated header %q", name)
+			}
+			size = 2 + int(binary.BigEndian.Uint16(b))
+		default:
+			return nil, fmt.Errorf("header %q has unknown value type %d", name, t)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("truncated header %q", name)
+		}
+
+		switch t {
+		case Int8ValueType:
+			v = Int8Value(b[0])
+		case Int16ValueType:
+			v = Int16Value(binary.BigEndian.Uint16(b))
+		case Int32ValueType:
+			v = Int32Value(binary.BigEndian.Uint32(b))
+		case Int64ValueType:
+			v = Int64Value(binary.BigEndian.Uint64(b))
+		case TimestampValueType:
+			v = TimestampValue(time.UnixMilli(int64(binary.BigEndian.Uint64(b))).UTC())
+		case UUIDValueType:
+			var u UUIDValue
+			copy(u[:], b)
+			v = u
+		case BytesValueType:
+			v = BytesValue(append([]byte(nil), b[2:size]...))
+		case StringValueType:
+			v = StringValue(b[2:size])
+		}
+		b = b[size:]
+		hs = append(hs, Header{Name: name, Value: v})
+	}
+	return hs, nil
+}
@@ -0,0 +1,139 @@
+// Package eventstream encodes and decodes messages in the
+// application/vnd.amazon.eventstream framing used by AWS bidirectional
+// streaming APIs. Each message is a prelude carrying the total and header
+// lengths and a CRC32 of the prelude, followed by typed headers, the
+// payload and a CRC32 of the whole message.
+//
+// Messages sent to AWS are wrapped in signed messages produced by
+// signer.StreamSigner.
+// Reference: AWS SDK for Go v2 aws/protocol/eventstream
+package eventstream
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+const (
+	// ContentType is the HTTP Content-Type of an event stream body.
+	ContentType = "application/vnd.amazon.eventstream"
+
+	// MaxPayloadLen is the largest payload of a single message.
+	MaxPayloadLen = 16 * 1024 * 1024
+
+	// MaxHeadersLen is the largest encoded headers section of a message.
+	MaxHeadersLen = 128 * 1024
+
+	preludeLen = 12
+	crcLen     = 4
+)
+
+// Message is an event stream message.
+type Message struct {
+	Headers Headers
+	Payload []byte
+}
+
+// Encoder writes messages to an io.Writer.
+type Encoder struct {
+	w       io.Writer
+	headers bytes.Buffer
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes msg as a single frame.
+func (e *Encoder) Encode(msg Message) error {
+	e.headers.Reset()
+	if err := EncodeHeaders(&e.headers, msg.Headers); err != nil {
+		return err
+	}
+	if e.headers.Len() > MaxHeadersLen {
+		return fmt.Errorf("headers length %d exceeds %d", e.headers.Len(), MaxHeadersLen)
+	}
+	if len(msg.Payload) > MaxPayloadLen {
+		return fmt.Errorf("payload length %d exceeds %d", len(msg.Payload), MaxPayloadLen)
+	}
+
+	total := preludeLen + e.headers.Len() + len(msg.Payload) + crcLen
+	frame := make([]byte, 0, total)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(total))
+	frame = binary.BigEndian.AppendUint32(frame, uint32(e.headers.Len()))
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+	frame = append(frame, e.headers.Bytes()...)
+	frame = append(frame, msg.Payload...)
+	frame = binary.BigEndian.AppendUint32(frame, crc32.ChecksumIEEE(frame))
+
+	_, err := e.w.Write(frame)
+	return err
+}
+
+// EncodeMessage returns the encoding of msg as a single frame.
+func EncodeMessage(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf).Encode(msg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decoder reads messages from an io.Reader.
+type Decoder struct {
+	r io.Reader
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Decode reads the next message, verifying both checksums. It returns
+// io.EOF when the stream ends cleanly between messages.
+func (d *Decoder) Decode() (Message, error) {
+	prelude := make([]byte, preludeLen)
+	if _, err := io.ReadFull(d.r, prelude); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Message{}, fmt.Errorf("truncated message prelude")
+		}
+		return Message{}, err
+	}
+
+	total := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc := binary.BigEndian.Uint32(prelude[8:12]); crc != crc32.ChecksumIEEE(prelude[:8]) {
+		return Message{}, fmt.Errorf("prelude checksum mismatch")
+	}
+	if headersLen > MaxHeadersLen {
+		return Message{}, fmt.Errorf("headers length %d exceeds %d", headersLen, MaxHeadersLen)
+	}
+	if total < preludeLen+crcLen+headersLen || total-preludeLen-crcLen-headersLen > MaxPayloadLen {
+		return Message{}, fmt.Errorf("invalid message length %d", total)
+	}
+
+	frame := make([]byte, total)
+	copy(frame, prelude)
+	if _, err := io.ReadFull(d.r, frame[preludeLen:]); err != nil {
+		return Message{}, fmt.Errorf("truncated message: %w", err)
+	}
+	end := total - crcLen
+	if crc := binary.BigEndian.Uint32(frame[end:]); crc != crc32.ChecksumIEEE(frame[:end]) {
+		return Message{}, fmt.Errorf("message checksum mismatch")
+	}
+
+	headers, err := decodeHeaders(frame[preludeLen : preludeLen+headersLen])
+	if err != nil {
+		return Message{}, err
+	}
+	return Message{Headers: headers, Payload: frame[preludeLen+headersLen : end]}, nil
+}
+
+// DecodeMessage decodes a single message from b.
+func DecodeMessage(b []byte) (Message, error) {
+	return NewDecoder(bytes.NewReader(b)).Decode()
+}
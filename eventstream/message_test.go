@@ -0,0 +1,121 @@
+package eventstream
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 123000000, time.UTC)
+	msg := Message{
+		Headers: Headers{
+			{Name: ":message-type", Value: StringValue("event")},
+			{Name: "true", Value: BoolValue(true)},
+			{Name: "false", Value: BoolValue(false)},
+			{Name: "int8", Value: Int8Value(-8)},
+			{Name: "int16", Value: Int16Value(-16)},
+			{Name: "int32", Value: Int32Value(-32)},
+			{Name: "int64", Value: Int64Value(-64)},
+			{Name: "bytes", Value: BytesValue{0, 1, 2}},
+			{Name: "timestamp", Value: TimestampValue(ts)},
+			{Name: "uuid", Value: UUIDValue{0: 1, 15: 16}},
+		},
+		Payload: []byte(`{"foo":"bar"}`),
+	}
+
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	if err := enc.Encode(msg); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if err := enc.Encode(Message{}); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	dec := NewDecoder(&buf)
+	got, err := dec.Decode()
+	if err != nil {
+		t.Fatalf("failed to decode: %v", err)
+	}
+	if !bytes.Equal(got.Payload, msg.Payload) {
+		t.Errorf("expected payload %q, got %q", msg.Payload, got.Payload)
+	}
+	if len(got.Headers) != len(msg.Headers) {
+		t.Fatalf("expected %d headers, got %d", len(msg.Headers), len(got.Headers))
+	}
+	for i, h := range msg.Headers {
+		g := got.Headers[i]
+		if g.Name != h.Name || g.Value.Type() != h.Value.Type() || g.Value.String() != h.Value.String() {
+			t.Errorf("header %d: expected %s=%s, got %s=%s", i, h.Name, h.Value, g.Name, g.Value)
+		}
+	}
+	if v := got.Headers.Get("timestamp"); !time.Time(v.(TimestampValue)).Equal(ts) {
+		t.Errorf("unexpected timestamp %v", v)
+	}
+
+	empty, err := dec.Decode()
+	if err != nil || len(empty.Headers) != 0 || len(empty.Payload) != 0 {
+		t.Errorf("expected empty message, got %+v, %v", empty, err)
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestEncodeKnownMessage(t *testing.T) {
+	// An empty message is a prelude and message CRC alone.
+	data, err := EncodeMessage(Message{})
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if got := hex.EncodeToString(data); got != "000000100000000005c248eb7d98c8ff" {
+		t.Errorf("unexpected encoding %s", got)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	valid, _ := EncodeMessage(Message{
+		Headers: Headers{{Name: "a", Value: StringValue("b")}},
+		Payload: []byte("payload"),
+	})
+
+	tests := []struct {
+		name   string
+		modify func(b []byte) []byte
+	}{
+		{"truncated prelude", func(b []byte) []byte { return b[:8] }},
+		{"truncated message", func(b []byte) []byte { return b[:len(b)-1] }},
+		{"prelude checksum", func(b []byte) []byte { b[8] ^= 1; return b }},
+		{"message checksum", func(b []byte) []byte { b[len(b)-6] ^= 1; return b }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.modify(append([]byte(nil), valid...))
+			if _, err := DecodeMessage(b); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestHeaders(t *testing.T) {
+	var hs Headers
+	hs.Set("a", StringValue("1"))
+	hs.Set("b", StringValue("2"))
+	hs.Set("a", StringValue("3"))
+	if len(hs) != 2 || hs.Get("a").String() != "3" {
+		t.Errorf("unexpected headers %v", hs)
+	}
+	hs.Del("b")
+	if hs.Get("b") != nil {
+		t.Error("expected header to be deleted")
+	}
+
+	long := make(BytesValue, maxHeaderValueLen+1)
+	if _, err := EncodeMessage(Message{Headers: Headers{{Name: "long", Value: long}}}); err == nil {
+		t.Error("expected error for oversized header value")
+	}
+}
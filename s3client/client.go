@@ -0,0 +1,190 @@
+// Package s3client is a minimal S3 object client built on the signer
+// package, using only the Go standard library. It is intended for
+// S3-compatible APIs such as Cloudflare R2 and MinIO.
+package s3client
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+// Client performs S3 object operations, signing each request with a
+// signer.Signer. A Client is safe for concurrent use when its Signer was
+// created with Config.ThreadSafety set.
+type Client struct {
+	signer     *signer.Signer
+	endpoint   *signer.S3Endpoint
+	httpClient *http.Client
+	now        func() time.Time
+	express    *ExpressSessions
+}
+
+// Options configures a Client.
+type Options struct {
+	// Endpoint is the service endpoint URL, for example
+	// "https://<account>.r2.cloudflarestorage.com".
+	Endpoint string
+
+	// ForcePathStyle addresses buckets in the URL path.
+	// See signer.S3Endpoint.
+	ForcePathStyle bool
+
+	// HTTPClient sends the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Now returns the signing time. Defaults to time.Now.
+	Now func() time.Time
+
+	// ExpressSessions, if set, authenticates requests to S3 Express One
+	// Zone directory buckets with per-bucket sessions. Presigned URLs
+	// are always signed with the Client's Signer.
+	ExpressSessions *ExpressSessions
+}
+
+// New creates a Client that signs requests with s.
+func New(s *signer.Signer, opts Options) (*Client, error) {
+	if s == nil {
+		return nil, fmt.Errorf("%w: signer is required", ErrInvalidArgument)
+	}
+
+	endpoint, err := signer.NewS3Endpoint(opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	endpoint.ForcePathStyle = opts.ForcePathStyle
+
+	c := &Client{
+		signer:     s,
+		endpoint:   endpoint,
+		httpClient: opts.HTTPClient,
+		now:        opts.Now,
+		express:    opts.ExpressSessions,
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	return c, nil
+}
+
+// request describes a single S3 API call.
+type request struct {
+	method string
+	bucket string
+	key    string
+	query  url.Values
+	header http.Header
+	body   io.ReadSeeker
+
+	// payloadHash and length, when payloadHash is set, are the hex SHA-256
+	// and remaining length of body, which is then not hashed again.
+	payloadHash string
+	length      int64
+
+	// checksumSHA256 sends the payload hash as an additional
+	// x-amz-checksum-sha256 header.
+	checksumSHA256 bool
+
+	// signer overrides the signer chosen for the bucket.
+	signer *signer.Signer
+}
+
+// do signs and sends r. Responses with a status of 300 or above are
+// returned as *Error, with the body consumed and closed.
+func (c *Client) do(ctx context.Context, r request) (*http.Response, error) {
+	req, err := c.endpoint.NewRequest(ctx, r.method, r.bucket, r.key, r.query, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+
+	payloadHash := signer.EmptyStringSHA256
+	if r.body != nil {
+		length := r.length
+		if r.payloadHash != "" {
+			payloadHash = r.payloadHash
+		} else if payloadHash, length, err = hashBody(r.body); err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(r.body)
+		req.ContentLength = length
+		if length == 0 {
+			req.Body = http.NoBody
+		}
+	}
+	req.Header.Set(signer.ContentSHAKey, payloadHash)
+	if r.checksumSHA256 {
+		req.Header.Set(checksumSHA256Header, checksumSHA256(payloadHash))
+	}
+
+	s := r.signer
+	if s == nil {
+		s = c.signer
+		if c.express != nil && IsDirectoryBucket(r.bucket) {
+			session, sessionSigner, err := c.express.entry(ctx, c, r.bucket)
+			if err != nil {
+				return nil, err
+			}
+			s = sessionSigner
+			req.Header.Set(ExpressSessionTokenHeader, session.SessionToken)
+		}
+	}
+
+	if err := s.SignHTTP(req, payloadHash, c.now()); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, parseError(resp)
+	}
+	return resp, nil
+}
+
+// checksumSHA256 returns the base64 x-amz-checksum-sha256 value of a hex
+// payload hash.
+func checksumSHA256(payloadHash string) string {
+	sum, _ := hex.DecodeString(payloadHash)
+	return base64.StdEncoding.EncodeToString(sum)
+}
+
+// hashBody computes the payload hash and remaining length of body and
+// rewinds it to its current position.
+func hashBody(body io.ReadSeeker) (string, int64, error) {
+	start, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to seek body: %w", err)
+	}
+	end, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to seek body: %w", err)
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to seek body: %w", err)
+	}
+
+	hash, err := signer.ComputePayloadHash(body)
+	if err != nil {
+		return "", 0, err
+	}
+	if _, err := body.Seek(start, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to seek body: %w", err)
+	}
+	return hash, end - start, nil
+}
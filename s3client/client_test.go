@@ -0,0 +1,303 @@
+package s3client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+// fakeS3 is a minimal path-style S3 handler for exercising the client.
+// It checks that requests are signed and that the declared payload hash
+// matches the body, but does not verify signatures.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]fakeObject
+	uploads map[string]map[int]fakePart
+}
+
+type fakeObject struct {
+	data   []byte
+	header http.Header
+}
+
+func newFakeS3() *fakeS3 {
+	return &fakeS3{
+		objects: make(map[string]fakeObject),
+		uploads: make(map[string]map[int]fakePart),
+	}
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	presigned := r.URL.Query().Get(signer.AmzSignatureKey) != ""
+	if !presigned && !strings.HasPrefix(r.Header.Get("Authorization"), signer.SigningAlgorithm) {
+		writeFakeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	sum := sha256.Sum256(body)
+	if !presigned && r.Header.Get(signer.ContentSHAKey) != hex.EncodeToString(sum[:]) {
+		writeFakeError(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if key == "" && r.Method == http.MethodGet {
+		f.list(w, r, bucket)
+		return
+	}
+	if q := r.URL.Query(); q.Has("uploads") || q.Has("uploadId") {
+		f.multipart(w, r, bucket+"/"+key, body)
+		return
+	}
+
+	name := bucket + "/" + key
+	obj, exists := f.objects[name]
+	etag := fmt.Sprintf("%q", hex.EncodeToString(sum[:8]))
+	if exists {
+		etag = obj.header.Get("ETag")
+	}
+
+	if m := r.Header.Get("If-Match"); m != "" && (!exists || m != etag) {
+		writeFakeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+		return
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" && exists && (m == "*" || m == etag) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		writeFakeError(w, http.StatusPreconditionFailed, "PreconditionFailed")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		h := make(http.Header)
+		h.Set("ETag", etag)
+		h.Set("Content-Type", r.Header.Get("Content-Type"))
+		for k, v := range r.Header {
+			if strings.HasPrefix(k, metaPrefix) {
+				h[k] = v
+			}
+		}
+		f.objects[name] = fakeObject{data: body, header: h}
+		w.Header().Set("ETag", etag)
+	case http.MethodGet, http.MethodHead:
+		if !exists {
+			writeFakeError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		for k, v := range obj.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(obj.data)))
+		w.Write(obj.data)
+	case http.MethodDelete:
+		delete(f.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, r *http.Request, bucket string) {
+	q := r.URL.Query()
+	prefix, token := q.Get("prefix"), q.Get("continuation-token")
+
+	var keys []string
+	for name := range f.objects {
+		key := strings.TrimPrefix(name, bucket+"/")
+		if key != name && strings.HasPrefix(key, prefix) && key > token {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	truncated := len(keys) > 2
+	if truncated {
+		keys = keys[:2]
+	}
+
+	var b strings.Builder
+	b.WriteString("<ListBucketResult><Name>" + bucket + "</Name>")
+	fmt.Fprintf(&b, "<KeyCount>%d</KeyCount><IsTruncated>%t</IsTruncated>", len(keys), truncated)
+	if truncated {
+		b.WriteString("<NextContinuationToken>" + keys[1] + "</NextContinuationToken>")
+	}
+	for _, k := range keys {
+		fmt.Fprintf(&b, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2023-12-01T12:00:00.000Z</LastModified></Contents>",
+			k, len(f.objects[bucket+"/"+k].data))
+	}
+	b.WriteString("</ListBucketResult>")
+	w.Write([]byte(b.String()))
+}
+
+func writeFakeError(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>fake</Message><RequestId>req-1</RequestId></Error>", code)
+}
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	server := httptest.NewServer(newFakeS3())
+	t.Cleanup(server.Close)
+
+	s, err := signer.NewSigner(signer.Config{
+		Region:          "auto",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+		ThreadSafety:    true,
+	})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+
+	client, err := New(s, Options{
+		Endpoint: server.URL,
+		Now:      func() time.Time { return time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC) },
+	})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return client
+}
+
+func TestPutGetHeadDelete(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	put, err := client.PutObject(ctx, "logs", "dir/a b.txt", strings.NewReader("hello"), &PutObjectInput{
+		ContentType: "text/plain",
+		Metadata:    map[string]string{"owner": "ingest"},
+	})
+	if err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+	if put.ETag == "" {
+		t.Error("expected ETag")
+	}
+
+	get, err := client.GetObject(ctx, "logs", "dir/a b.txt", nil)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	data, _ := io.ReadAll(get.Body)
+	get.Body.Close()
+	if string(data) != "hello" {
+		t.Errorf("expected hello, got %q", data)
+	}
+	if get.ContentType != "text/plain" || get.Metadata["owner"] != "ingest" || get.ETag != put.ETag {
+		t.Errorf("unexpected object info %+v", get.ObjectInfo)
+	}
+
+	info, err := client.HeadObject(ctx, "logs", "dir/a b.txt", &Conditions{IfMatch: put.ETag})
+	if err != nil {
+		t.Fatalf("HeadObject: %v", err)
+	}
+	if info.ContentLength != 5 {
+		t.Errorf("expected content length 5, got %d", info.ContentLength)
+	}
+
+	if err := client.DeleteObject(ctx, "logs", "dir/a b.txt"); err != nil {
+		t.Fatalf("DeleteObject: %v", err)
+	}
+
+	_, err = client.HeadObject(ctx, "logs", "dir/a b.txt", nil)
+	if !IsNotFound(err) {
+		t.Errorf("expected not found error, got %v", err)
+	}
+}
+
+func TestConditionalRequests(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	put, err := client.PutObject(ctx, "logs", "a", strings.NewReader("v1"), &PutObjectInput{IfNoneMatch: "*"})
+	if err != nil {
+		t.Fatalf("PutObject: %v", err)
+	}
+
+	_, err = client.PutObject(ctx, "logs", "a", strings.NewReader("v2"), &PutObjectInput{IfNoneMatch: "*"})
+	if ErrorCode(err) != "PreconditionFailed" {
+		t.Errorf("expected PreconditionFailed, got %v", err)
+	}
+
+	_, err = client.GetObject(ctx, "logs", "a", &GetObjectInput{
+		Conditions: Conditions{IfNoneMatch: put.ETag},
+	})
+	if ErrorCode(err) != "NotModified" {
+		t.Errorf("expected NotModified, got %v", err)
+	}
+
+	_, err = client.GetObject(ctx, "logs", "a", &GetObjectInput{
+		Conditions: Conditions{IfMatch: `"other"`},
+	})
+	var s3err *Error
+	if !errors.As(err, &s3err) || s3err.StatusCode != http.StatusPreconditionFailed || s3err.RequestID != "req-1" {
+		t.Errorf("expected precondition error, got %v", err)
+	}
+}
+
+func TestListObjectsV2(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"logs/1", "logs/2", "logs/3", "other/1"} {
+		if _, err := client.PutObject(ctx, "bucket", key, strings.NewReader(key), nil); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+	}
+
+	var keys []string
+	in := &ListObjectsV2Input{Prefix: "logs/"}
+	for {
+		out, err := client.ListObjectsV2(ctx, "bucket", in)
+		if err != nil {
+			t.Fatalf("ListObjectsV2: %v", err)
+		}
+		for _, obj := range out.Contents {
+			keys = append(keys, obj.Key)
+			if obj.Size != int64(len(obj.Key)) || obj.LastModified.IsZero() {
+				t.Errorf("unexpected object %+v", obj)
+			}
+		}
+		if !out.IsTruncated {
+			break
+		}
+		in.ContinuationToken = out.NextContinuationToken
+	}
+
+	if strings.Join(keys, ",") != "logs/1,logs/2,logs/3" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
+
+func TestUnsignedRequestRejected(t *testing.T) {
+	server := httptest.NewServer(newFakeS3())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/bucket/key")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if err := parseError(resp); ErrorCode(err) != "AccessDenied" {
+		t.Errorf("expected AccessDenied, got %v", err)
+	}
+}
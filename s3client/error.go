@@ -0,0 +1,120 @@
+package s3client
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Errors returned, possibly wrapped, by the Client. Test for them with
+// errors.Is.
+var (
+	// ErrInvalidArgument is returned for arguments that cannot be sent,
+	// before any request is made.
+	ErrInvalidArgument = errors.New("s3: invalid argument")
+
+	// ErrInvalidResponse is returned for a successful response whose body
+	// cannot be decoded or lacks a required field.
+	ErrInvalidResponse = errors.New("s3: invalid response")
+)
+
+// maxErrorBodySize bounds how much of an error response body is parsed.
+const maxErrorBodySize = 64 << 10
+
+// Error is an S3 error response.
+// Reference: Amazon S3 API Reference, "Error responses"
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+
+	// Code is the S3 error code, e.g. "NoSuchKey". Responses without an
+	// XML body, such as those to HEAD requests, are given a code derived
+	// from the status code.
+	Code string
+
+	// Message is the human readable error message, if any.
+	Message string
+
+	// Resource is the bucket or object the error applies to, if any.
+	Resource string
+
+	// RequestID is the request ID assigned by the service, if any.
+	RequestID string
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("s3: %s (status %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("s3: %s: %s (status %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// ErrorCode returns the S3 error code of err, or "" if err is not an
+// *Error.
+func ErrorCode(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return ""
+}
+
+// IsNotFound reports whether err is an S3 error for a missing bucket,
+// object or multipart upload.
+func IsNotFound(err error) bool {
+	switch ErrorCode(err) {
+	case "NotFound", "NoSuchKey", "NoSuchBucket", "NoSuchUpload":
+		return true
+	}
+	return false
+}
+
+// parseError builds an *Error from a failed response.
+func parseError(resp *http.Response) error {
+	e := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Amz-Request-Id"),
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	var doc struct {
+		Code      string `xml:"Code"`
+		Message   string `xml:"Message"`
+		Resource  string `xml:"Resource"`
+		RequestID string `xml:"RequestId"`
+	}
+	if len(body) > 0 && xml.Unmarshal(body, &doc) == nil {
+		e.Code = doc.Code
+		e.Message = doc.Message
+		e.Resource = doc.Resource
+		if doc.RequestID != "" {
+			e.RequestID = doc.RequestID
+		}
+	}
+
+	if e.Code == "" {
+		e.Code = statusErrorCode(resp.StatusCode)
+	}
+	return e
+}
+
+// statusErrorCode maps status codes to the codes S3 uses when it cannot
+// return an error body.
+func statusErrorCode(status int) string {
+	switch status {
+	case http.StatusNotModified:
+		return "NotModified"
+	case http.StatusBadRequest:
+		return "BadRequest"
+	case http.StatusForbidden:
+		return "Forbidden"
+	case http.StatusNotFound:
+		return "NotFound"
+	case http.StatusPreconditionFailed:
+		return "PreconditionFailed"
+	}
+	return http.StatusText(status)
+}
@@ -0,0 +1,217 @@
+package s3client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+const (
+	// ExpressService is the signing name for S3 Express One Zone.
+	ExpressService = "s3express"
+
+	// ExpressSessionTokenHeader carries the session token of an S3
+	// Express session, in place of X-Amz-Security-Token.
+	ExpressSessionTokenHeader = "X-Amz-S3session-Token"
+
+	// DefaultExpressRefreshWindow is how long before expiry a session is
+	// replaced.
+	DefaultExpressRefreshWindow = time.Minute
+
+	// directoryBucketSuffix ends the name of every directory bucket.
+	directoryBucketSuffix = "--x-s3"
+)
+
+// IsDirectoryBucket reports whether bucket names an S3 Express One Zone
+// directory bucket, e.g. "logs--usw2-az1--x-s3".
+func IsDirectoryBucket(bucket string) bool {
+	return strings.HasSuffix(bucket, directoryBucketSuffix)
+}
+
+// ExpressSession holds the short-lived credentials returned by
+// CreateSession.
+type ExpressSession struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expiration      time.Time
+}
+
+// String formats the ExpressSession with SecretAccessKey and SessionToken
+// redacted.
+func (s ExpressSession) String() string {
+	return fmt.Sprintf("ExpressSession{AccessKeyID: %s, SecretAccessKey: %s, SessionToken: %s, Expiration: %s}",
+		s.AccessKeyID, redact(s.SecretAccessKey), redact(s.SessionToken), s.Expiration.Format(time.RFC3339))
+}
+
+// GoString formats the ExpressSession for %#v as String does.
+func (s ExpressSession) GoString() string {
+	return "s3client." + s.String()
+}
+
+// redact returns a placeholder for a non-empty secret.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+// ExpressSessions creates and caches S3 Express sessions per directory
+// bucket. A Client with Options.ExpressSessions set signs requests to
+// directory buckets with the session credentials, the s3express service
+// and the session token header. It is safe for concurrent use.
+// Reference: Amazon S3 API Reference, "CreateSession"
+type ExpressSessions struct {
+	// RefreshWindow is how long before expiry a session is replaced.
+	// Defaults to DefaultExpressRefreshWindow.
+	RefreshWindow time.Duration
+
+	// sessionConfig is the Config of session signers, without
+	// credentials.
+	sessionConfig signer.Config
+	signer        *signer.Signer
+
+	mu       sync.Mutex
+	sessions map[string]*expressEntry
+}
+
+// expressEntry is the cached session of one bucket. Its mutex is held
+// while the session is refreshed, so concurrent requests wait for a
+// single CreateSession call.
+type expressEntry struct {
+	mu      sync.Mutex
+	session *ExpressSession
+	signer  *signer.Signer
+}
+
+// NewExpressSessions creates a session cache that calls CreateSession
+// with the long-term credentials and region in config. Session signers
+// share its HeaderRules, Logger and Observer. config.Service is ignored.
+func NewExpressSessions(config signer.Config) (*ExpressSessions, error) {
+	config.Service = ExpressService
+	config.ThreadSafety = true
+	s, err := signer.NewSigner(config)
+	if err != nil {
+		return nil, err
+	}
+	return &ExpressSessions{
+		sessionConfig: signer.Config{
+			Region:       config.Region,
+			Service:      ExpressService,
+			HeaderRules:  config.HeaderRules,
+			ThreadSafety: true,
+			Logger:       config.Logger,
+			Observer:     config.Observer,
+		},
+		signer:   s,
+		sessions: make(map[string]*expressEntry),
+	}, nil
+}
+
+// String formats the ExpressSessions without the credentials of its
+// signers and sessions.
+func (m *ExpressSessions) String() string {
+	return fmt.Sprintf("ExpressSessions{Region: %s, RefreshWindow: %s}", m.sessionConfig.Region, m.RefreshWindow)
+}
+
+// GoString formats the ExpressSessions for %#v as String does.
+func (m *ExpressSessions) GoString() string {
+	return "&s3client." + m.String()
+}
+
+// entry returns the current session of bucket, creating one with c if
+// there is none or it is within the refresh window of expiry.
+func (m *ExpressSessions) entry(ctx context.Context, c *Client, bucket string) (*ExpressSession, *signer.Signer, error) {
+	m.mu.Lock()
+	e, ok := m.sessions[bucket]
+	if !ok {
+		e = &expressEntry{}
+		m.sessions[bucket] = e
+	}
+	m.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	window := m.RefreshWindow
+	if window == 0 {
+		window = DefaultExpressRefreshWindow
+	}
+	if e.session != nil && c.now().Add(window).Before(e.session.Expiration) {
+		return e.session, e.signer, nil
+	}
+
+	session, err := c.createSession(ctx, bucket, m.signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	config := m.sessionConfig
+	config.AccessKeyID = session.AccessKeyID
+	config.SecretAccessKey = session.SecretAccessKey
+	sessionSigner, err := signer.NewSigner(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid session credentials: %w", err)
+	}
+	// The previous session is being replaced, so its cached keys are
+	// wiped rather than left until the signer is collected.
+	if e.signer != nil {
+		e.signer.Close()
+	}
+	e.session, e.signer = session, sessionSigner
+	return session, sessionSigner, nil
+}
+
+// CreateSession creates an S3 Express session for the directory bucket
+// using the credentials of the Client's ExpressSessions. Sessions are
+// created as needed by requests to directory buckets, so this is only
+// needed to manage sessions directly.
+func (c *Client) CreateSession(ctx context.Context, bucket string) (*ExpressSession, error) {
+	if c.express == nil {
+		return nil, fmt.Errorf("%w: client has no ExpressSessions", ErrInvalidArgument)
+	}
+	return c.createSession(ctx, bucket, c.express.signer)
+}
+
+// createSession calls CreateSession signed with s.
+func (c *Client) createSession(ctx context.Context, bucket string, s *signer.Signer) (*ExpressSession, error) {
+	resp, err := c.do(ctx, request{
+		method: http.MethodGet,
+		bucket: bucket,
+		query:  url.Values{"session": {""}},
+		signer: s,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"Credentials"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CreateSession response: %w", ErrInvalidResponse, err)
+	}
+	creds := result.Credentials
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" || creds.SessionToken == "" {
+		return nil, fmt.Errorf("%w: CreateSession response has no credentials", ErrInvalidResponse)
+	}
+	return &ExpressSession{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expiration:      creds.Expiration,
+	}, nil
+}
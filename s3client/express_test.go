@@ -0,0 +1,169 @@
+package s3client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+// fakeExpress serves CreateSession and GetObject for directory buckets,
+// verifying signatures with the long-term or session credentials.
+type fakeExpress struct {
+	config   signer.Config
+	now      func() time.Time
+	mu       sync.Mutex
+	sessions map[string]signer.Config
+	created  int
+}
+
+func (f *fakeExpress) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payloadHash := r.Header.Get(signer.ContentSHAKey)
+	if r.URL.Query().Has("session") {
+		verifier, _ := signer.NewVerifier(f.config)
+		if err := verifier.VerifyHTTP(r, payloadHash, f.now()); err != nil {
+			writeFakeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+			return
+		}
+		f.created++
+		session := signer.Config{
+			Region:          f.config.Region,
+			AccessKeyID:     fmt.Sprintf("ASIASESSION%d", f.created),
+			SecretAccessKey: fmt.Sprintf("session-secret-%d", f.created),
+			Service:         ExpressService,
+		}
+		token := fmt.Sprintf("token-%d", f.created)
+		f.sessions[token] = session
+		fmt.Fprintf(w, `<CreateSessionResult><Credentials><SessionToken>%s</SessionToken>`+
+			`<SecretAccessKey>%s</SecretAccessKey><AccessKeyId>%s</AccessKeyId>`+
+			`<Expiration>%s</Expiration></Credentials></CreateSessionResult>`,
+			token, session.SecretAccessKey, session.AccessKeyID, f.now().Add(5*time.Minute).UTC().Format(time.RFC3339))
+		return
+	}
+
+	session, ok := f.sessions[r.Header.Get(ExpressSessionTokenHeader)]
+	if !ok || r.Header.Get(signer.AmzSecurityTokenKey) != "" {
+		writeFakeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	verifier, _ := signer.NewVerifier(session)
+	if err := verifier.VerifyHTTP(r, payloadHash, f.now()); err != nil {
+		writeFakeError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+	if !strings.Contains(r.Header.Get(signer.AuthorizationHeader), "x-amz-s3session-token") {
+		writeFakeError(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	io.WriteString(w, "express data")
+}
+
+func TestExpressSessions(t *testing.T) {
+	config := signer.Config{
+		Region:          "us-west-2",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+		Service:         ExpressService,
+	}
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	fake := &fakeExpress{config: config, now: clock, sessions: make(map[string]signer.Config)}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	// The observer sees signing by the session signers as well.
+	var signs int
+	observed := config
+	observed.Observer = signer.ObserverFunc(func(ctx context.Context, obs signer.Observation) {
+		if obs.Operation == signer.OperationSign {
+			signs++
+		}
+	})
+	sessions, err := NewExpressSessions(observed)
+	if err != nil {
+		t.Fatalf("failed to create sessions: %v", err)
+	}
+	base, _ := signer.NewSigner(signer.Config{Region: "us-west-2", AccessKeyID: "AKID", SecretAccessKey: "SECRET"})
+	client, _ := New(base, Options{Endpoint: server.URL, Now: clock, ExpressSessions: sessions})
+	ctx := context.Background()
+	bucket := "logs--usw2-az1--x-s3"
+
+	get := func() {
+		t.Helper()
+		out, err := client.GetObject(ctx, bucket, "key", nil)
+		if err != nil {
+			t.Fatalf("GetObject: %v", err)
+		}
+		data, _ := io.ReadAll(out.Body)
+		out.Body.Close()
+		if string(data) != "express data" {
+			t.Errorf("unexpected data %q", data)
+		}
+	}
+
+	get()
+	if signs != 2 {
+		t.Errorf("expected CreateSession and GetObject signing to be observed, got %d", signs)
+	}
+	get()
+	if fake.created != 1 {
+		t.Errorf("expected session to be reused, created %d", fake.created)
+	}
+
+	// Within the refresh window, a new session is created and the signer
+	// of the previous one closed.
+	previous := sessions.sessions[bucket].signer
+	now = now.Add(4*time.Minute + 30*time.Second)
+	get()
+	if fake.created != 2 {
+		t.Errorf("expected session to be refreshed, created %d", fake.created)
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/"+bucket+"/key", nil)
+	if err := previous.SignHTTP(req, signer.EmptyStringSHA256, now); !errors.Is(err, signer.ErrClosed) {
+		t.Errorf("expected previous session signer to be closed, got %v", err)
+	}
+
+	session, err := client.CreateSession(ctx, bucket)
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+	if session.AccessKeyID != "ASIASESSION3" || !session.Expiration.Equal(now.Add(5*time.Minute)) {
+		t.Errorf("unexpected session %+v", session)
+	}
+}
+
+func TestIsDirectoryBucket(t *testing.T) {
+	if !IsDirectoryBucket("logs--usw2-az1--x-s3") {
+		t.Error("expected directory bucket")
+	}
+	if IsDirectoryBucket("logs") {
+		t.Error("expected general purpose bucket")
+	}
+}
+
+func TestExpressSessionRedaction(t *testing.T) {
+	session := ExpressSession{AccessKeyID: "ASIASESSION", SecretAccessKey: "SESSIONSECRET", SessionToken: "SESSIONTOKEN"}
+	sessions, _ := NewExpressSessions(signer.Config{Region: "us-west-2", AccessKeyID: "AKID", SecretAccessKey: "LONGTERMSECRET"})
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		for _, value := range []interface{}{session, &session, sessions} {
+			out := fmt.Sprintf(format, value)
+			for _, secret := range []string{"SESSIONSECRET", "SESSIONTOKEN", "LONGTERMSECRET"} {
+				if strings.Contains(out, secret) {
+					t.Errorf("%s leaks a secret: %s", format, out)
+				}
+			}
+		}
+	}
+}
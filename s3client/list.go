@@ -0,0 +1,88 @@
+package s3client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListObjectsV2Input holds the optional parameters of ListObjectsV2.
+type ListObjectsV2Input struct {
+	Prefix            string
+	Delimiter         string
+	ContinuationToken string
+	StartAfter        string
+
+	// MaxKeys limits the number of keys returned. Zero uses the service
+	// default of 1000.
+	MaxKeys int
+}
+
+// Object is an entry in a ListObjectsV2 result.
+type Object struct {
+	Key          string    `xml:"Key"`
+	LastModified time.Time `xml:"LastModified"`
+	ETag         string    `xml:"ETag"`
+	Size         int64     `xml:"Size"`
+	StorageClass string    `xml:"StorageClass"`
+}
+
+// ListObjectsV2Output is a single page of a ListObjectsV2 result.
+// When IsTruncated is set, pass NextContinuationToken as the
+// ContinuationToken of the next call.
+type ListObjectsV2Output struct {
+	Name                  string   `xml:"Name"`
+	Prefix                string   `xml:"Prefix"`
+	Delimiter             string   `xml:"Delimiter"`
+	MaxKeys               int      `xml:"MaxKeys"`
+	KeyCount              int      `xml:"KeyCount"`
+	IsTruncated           bool     `xml:"IsTruncated"`
+	ContinuationToken     string   `xml:"ContinuationToken"`
+	NextContinuationToken string   `xml:"NextContinuationToken"`
+	StartAfter            string   `xml:"StartAfter"`
+	Contents              []Object `xml:"Contents"`
+	CommonPrefixes        []string `xml:"CommonPrefixes>Prefix"`
+}
+
+// ListObjectsV2 lists one page of the objects in bucket.
+// Reference: Amazon S3 API Reference, "ListObjectsV2"
+func (c *Client) ListObjectsV2(ctx context.Context, bucket string, in *ListObjectsV2Input) (*ListObjectsV2Output, error) {
+	query := url.Values{}
+	query.Set("list-type", "2")
+	if in != nil {
+		setQueryIfNotEmpty(query, "prefix", in.Prefix)
+		setQueryIfNotEmpty(query, "delimiter", in.Delimiter)
+		setQueryIfNotEmpty(query, "continuation-token", in.ContinuationToken)
+		setQueryIfNotEmpty(query, "start-after", in.StartAfter)
+		if in.MaxKeys > 0 {
+			query.Set("max-keys", strconv.Itoa(in.MaxKeys))
+		}
+	}
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodGet,
+		bucket: bucket,
+		query:  query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	out := &ListObjectsV2Output{}
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode ListObjectsV2 response: %w", ErrInvalidResponse, err)
+	}
+	return out, nil
+}
+
+// setQueryIfNotEmpty sets a query parameter only when value is non-empty.
+func setQueryIfNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
@@ -0,0 +1,389 @@
+package s3client
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+const (
+	// checksumSHA256Header carries the base64 SHA-256 checksum of a part.
+	checksumSHA256Header = "X-Amz-Checksum-Sha256"
+
+	// checksumAlgorithmHeader selects the checksum algorithm of an upload.
+	checksumAlgorithmHeader = "X-Amz-Checksum-Algorithm"
+
+	// maxPartNumber is the highest part number of a multipart upload.
+	maxPartNumber = 10000
+)
+
+// CompletedPart is an uploaded part of a multipart upload.
+type CompletedPart struct {
+	PartNumber     int    `xml:"PartNumber"`
+	ETag           string `xml:"ETag"`
+	ChecksumSHA256 string `xml:"ChecksumSHA256,omitempty"`
+}
+
+// MultipartUpload is an in-progress multipart upload. It collects the
+// parts uploaded through it so that CompleteMultipartUpload can assemble
+// the completion request. A MultipartUpload is safe for concurrent use, so
+// parts may be uploaded in parallel.
+type MultipartUpload struct {
+	Bucket   string
+	Key      string
+	UploadID string
+
+	// ChecksumSHA256 is set when parts carry SHA-256 checksums.
+	ChecksumSHA256 bool
+
+	mu    sync.Mutex
+	parts map[int]CompletedPart
+}
+
+// AddPart records a part uploaded outside UploadPart, for example through
+// a presigned URL. A part with the same number replaces any earlier one.
+func (u *MultipartUpload) AddPart(part CompletedPart) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.parts == nil {
+		u.parts = make(map[int]CompletedPart)
+	}
+	u.parts[part.PartNumber] = part
+}
+
+// Parts returns the recorded parts in part number order.
+func (u *MultipartUpload) Parts() []CompletedPart {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	parts := make([]CompletedPart, 0, len(u.parts))
+	for _, p := range u.parts {
+		parts = append(parts, p)
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].PartNumber < parts[j].PartNumber
+	})
+	return parts
+}
+
+// CreateMultipartUploadInput holds the optional parameters of
+// CreateMultipartUpload.
+type CreateMultipartUploadInput struct {
+	ContentType string
+
+	// Metadata is sent as x-amz-meta-* headers.
+	Metadata map[string]string
+
+	// ChecksumSHA256 requests SHA-256 checksums for every part.
+	ChecksumSHA256 bool
+}
+
+// CreateMultipartUpload starts a multipart upload.
+// Reference: Amazon S3 API Reference, "CreateMultipartUpload"
+func (c *Client) CreateMultipartUpload(ctx context.Context, bucket, key string, in *CreateMultipartUploadInput) (*MultipartUpload, error) {
+	header := make(http.Header)
+	checksum := false
+	if in != nil {
+		setIfNotEmpty(header, "Content-Type", in.ContentType)
+		for k, v := range in.Metadata {
+			header.Set(metaPrefix+k, v)
+		}
+		if in.ChecksumSHA256 {
+			header.Set(checksumAlgorithmHeader, "SHA256")
+			checksum = true
+		}
+	}
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodPost,
+		bucket: bucket,
+		key:    key,
+		query:  url.Values{"uploads": {""}},
+		header: header,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CreateMultipartUpload response: %w", ErrInvalidResponse, err)
+	}
+	if out.UploadID == "" {
+		return nil, fmt.Errorf("%w: CreateMultipartUpload response has no upload ID", ErrInvalidResponse)
+	}
+
+	return &MultipartUpload{
+		Bucket:         bucket,
+		Key:            key,
+		UploadID:       out.UploadID,
+		ChecksumSHA256: checksum,
+	}, nil
+}
+
+// UploadPart uploads part partNumber (1 to 10000) of u and records its
+// ETag and checksum in u. The checksum is the one S3 returns, or the one
+// sent if the service does not return it.
+// Reference: Amazon S3 API Reference, "UploadPart"
+func (c *Client) UploadPart(ctx context.Context, u *MultipartUpload, partNumber int, body io.ReadSeeker) (*CompletedPart, error) {
+	if err := checkPartNumber(partNumber); err != nil {
+		return nil, err
+	}
+	r := request{
+		method:         http.MethodPut,
+		bucket:         u.Bucket,
+		key:            u.Key,
+		query:          partQuery(u.UploadID, partNumber),
+		body:           body,
+		checksumSHA256: u.ChecksumSHA256,
+	}
+	if u.ChecksumSHA256 && body != nil {
+		var err error
+		if r.payloadHash, r.length, err = hashBody(body); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	part := CompletedPart{
+		PartNumber: partNumber,
+		ETag:       resp.Header.Get("ETag"),
+	}
+	if part.ETag == "" {
+		return nil, fmt.Errorf("%w: UploadPart response has no ETag", ErrInvalidResponse)
+	}
+	if u.ChecksumSHA256 {
+		part.ChecksumSHA256 = resp.Header.Get(checksumSHA256Header)
+		if part.ChecksumSHA256 == "" {
+			payloadHash := r.payloadHash
+			if payloadHash == "" {
+				payloadHash = signer.EmptyStringSHA256
+			}
+			part.ChecksumSHA256 = checksumSHA256(payloadHash)
+		}
+	}
+	u.AddPart(part)
+	return &part, nil
+}
+
+// CompleteMultipartUploadOutput is the result of CompleteMultipartUpload.
+type CompleteMultipartUploadOutput struct {
+	Location string `xml:"Location"`
+	Bucket   string `xml:"Bucket"`
+	Key      string `xml:"Key"`
+	ETag     string `xml:"ETag"`
+}
+
+// CompleteMultipartUpload assembles the parts recorded in u into the
+// final object.
+// Reference: Amazon S3 API Reference, "CompleteMultipartUpload"
+func (c *Client) CompleteMultipartUpload(ctx context.Context, u *MultipartUpload) (*CompleteMultipartUploadOutput, error) {
+	parts := u.Parts()
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("%w: multipart upload %s has no parts", ErrInvalidArgument, u.UploadID)
+	}
+
+	doc := struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []CompletedPart `xml:"Part"`
+	}{Parts: parts}
+	body, err := xml.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodPost,
+		bucket: u.Bucket,
+		key:    u.Key,
+		query:  url.Values{"uploadId": {u.UploadID}},
+		header: http.Header{"Content-Type": {"application/xml"}},
+		body:   bytes.NewReader(body),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// S3 may report a failed completion with a 200 status and an Error
+	// document, so the body must be inspected.
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var root struct{ XMLName xml.Name }
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CompleteMultipartUpload response: %w", ErrInvalidResponse, err)
+	}
+	if root.XMLName.Local == "Error" {
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		return nil, parseError(resp)
+	}
+
+	out := &CompleteMultipartUploadOutput{}
+	if err := xml.Unmarshal(data, out); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode CompleteMultipartUpload response: %w", ErrInvalidResponse, err)
+	}
+	return out, nil
+}
+
+// AbortMultipartUpload aborts u and discards its uploaded parts.
+// Reference: Amazon S3 API Reference, "AbortMultipartUpload"
+func (c *Client) AbortMultipartUpload(ctx context.Context, u *MultipartUpload) error {
+	resp, err := c.do(ctx, request{
+		method: http.MethodDelete,
+		bucket: u.Bucket,
+		key:    u.Key,
+		query:  url.Values{"uploadId": {u.UploadID}},
+	})
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// ListPartsInput holds the optional parameters of ListParts.
+type ListPartsInput struct {
+	PartNumberMarker int
+
+	// MaxParts limits the number of parts returned. Zero uses the
+	// service default of 1000.
+	MaxParts int
+}
+
+// Part is an entry in a ListParts result.
+type Part struct {
+	PartNumber     int       `xml:"PartNumber"`
+	ETag           string    `xml:"ETag"`
+	Size           int64     `xml:"Size"`
+	LastModified   time.Time `xml:"LastModified"`
+	ChecksumSHA256 string    `xml:"ChecksumSHA256"`
+}
+
+// ListPartsOutput is a single page of a ListParts result.
+// When IsTruncated is set, pass NextPartNumberMarker as the
+// PartNumberMarker of the next call.
+type ListPartsOutput struct {
+	Bucket               string `xml:"Bucket"`
+	Key                  string `xml:"Key"`
+	UploadID             string `xml:"UploadId"`
+	PartNumberMarker     int    `xml:"PartNumberMarker"`
+	NextPartNumberMarker int    `xml:"NextPartNumberMarker"`
+	MaxParts             int    `xml:"MaxParts"`
+	IsTruncated          bool   `xml:"IsTruncated"`
+	Parts                []Part `xml:"Part"`
+}
+
+// ListParts lists one page of the parts uploaded for an upload.
+// Reference: Amazon S3 API Reference, "ListParts"
+func (c *Client) ListParts(ctx context.Context, bucket, key, uploadID string, in *ListPartsInput) (*ListPartsOutput, error) {
+	query := url.Values{"uploadId": {uploadID}}
+	if in != nil {
+		if in.PartNumberMarker > 0 {
+			query.Set("part-number-marker", strconv.Itoa(in.PartNumberMarker))
+		}
+		if in.MaxParts > 0 {
+			query.Set("max-parts", strconv.Itoa(in.MaxParts))
+		}
+	}
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodGet,
+		bucket: bucket,
+		key:    key,
+		query:  query,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	out := &ListPartsOutput{}
+	if err := xml.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode ListParts response: %w", ErrInvalidResponse, err)
+	}
+	return out, nil
+}
+
+// PresignedPart is a presigned URL for uploading one part with PUT.
+// Header holds the signed headers that must be sent with the request.
+type PresignedPart struct {
+	PartNumber int
+	URL        string
+	Header     http.Header
+}
+
+// PresignUploadParts presigns a PUT URL for each of partNumbers of u, so
+// that a browser or separate worker can upload the parts directly. The
+// payload is unsigned. The uploader must report each part's ETag back,
+// to be recorded with AddPart before completing the upload.
+func (c *Client) PresignUploadParts(ctx context.Context, u *MultipartUpload, partNumbers []int, expires time.Duration) ([]PresignedPart, error) {
+	if expires < time.Second || expires > signer.MaxPresignExpiry {
+		return nil, fmt.Errorf("%w: presign expiry must be between 1s and %s", ErrInvalidArgument, signer.MaxPresignExpiry)
+	}
+
+	for _, n := range partNumbers {
+		if err := checkPartNumber(n); err != nil {
+			return nil, err
+		}
+	}
+
+	now := c.now()
+	parts := make([]PresignedPart, 0, len(partNumbers))
+	for _, n := range partNumbers {
+		query := partQuery(u.UploadID, n)
+		query.Set(signer.AmzExpiresKey, strconv.Itoa(int(expires/time.Second)))
+
+		req, err := c.endpoint.NewRequest(ctx, http.MethodPut, u.Bucket, u.Key, query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		signedURL, header, err := c.signer.PresignHTTP(req, signer.UnsignedPayload, now)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, PresignedPart{
+			PartNumber: n,
+			URL:        signedURL,
+			Header:     header,
+		})
+	}
+	return parts, nil
+}
+
+// checkPartNumber rejects part numbers that S3 does not accept.
+func checkPartNumber(partNumber int) error {
+	if partNumber < 1 || partNumber > maxPartNumber {
+		return fmt.Errorf("%w: part number %d is not between 1 and %d", ErrInvalidArgument, partNumber, maxPartNumber)
+	}
+	return nil
+}
+
+// partQuery returns the query parameters addressing one part of an upload.
+func partQuery(uploadID string, partNumber int) url.Values {
+	return url.Values{
+		"partNumber": {strconv.Itoa(partNumber)},
+		"uploadId":   {uploadID},
+	}
+}
@@ -0,0 +1,232 @@
+package s3client
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+type fakePart struct {
+	data     []byte
+	etag     string
+	checksum string
+}
+
+// multipart handles the multipart upload operations of fakeS3.
+// Invalid completions are reported with a 200 status and an Error
+// document, as S3 does.
+func (f *fakeS3) multipart(w http.ResponseWriter, r *http.Request, name string, body []byte) {
+	q := r.URL.Query()
+	uploadID := q.Get("uploadId")
+	parts, ok := f.uploads[uploadID]
+	if uploadID != "" && !ok {
+		writeFakeError(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		uploadID = fmt.Sprintf("upload-%d", len(f.uploads)+1)
+		f.uploads[uploadID] = make(map[int]fakePart)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", uploadID)
+
+	case r.Method == http.MethodPut:
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		sum := sha256.Sum256(body)
+		part := fakePart{
+			data:     body,
+			etag:     fmt.Sprintf("%q", hex.EncodeToString(sum[:4])),
+			checksum: base64.StdEncoding.EncodeToString(sum[:]),
+		}
+		if c := r.Header.Get(checksumSHA256Header); c != "" && c != part.checksum {
+			writeFakeError(w, http.StatusBadRequest, "BadDigest")
+			return
+		}
+		parts[n] = part
+		// Like some S3-compatible stores, the fake does not echo the
+		// checksum, so the client records the one it sent.
+		w.Header().Set("ETag", part.etag)
+
+	case r.Method == http.MethodGet:
+		var b strings.Builder
+		b.WriteString("<ListPartsResult><UploadId>" + uploadID + "</UploadId>")
+		for n := 1; n <= len(parts); n++ {
+			fmt.Fprintf(&b, "<Part><PartNumber>%d</PartNumber><ETag>%s</ETag><Size>%d</Size></Part>",
+				n, parts[n].etag, len(parts[n].data))
+		}
+		b.WriteString("</ListPartsResult>")
+		w.Write([]byte(b.String()))
+
+	case r.Method == http.MethodPost:
+		var doc struct {
+			Parts []CompletedPart `xml:"Part"`
+		}
+		xml.Unmarshal(body, &doc)
+
+		var data []byte
+		for _, p := range doc.Parts {
+			stored, ok := parts[p.PartNumber]
+			if !ok || stored.etag != p.ETag || (p.ChecksumSHA256 != "" && stored.checksum != p.ChecksumSHA256) {
+				writeFakeError(w, http.StatusOK, "InvalidPart")
+				return
+			}
+			data = append(data, stored.data...)
+		}
+		delete(f.uploads, uploadID)
+		f.objects[name] = fakeObject{data: data, header: http.Header{"Etag": {`"complete"`}}}
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Key>%s</Key><ETag>&quot;complete&quot;</ETag></CompleteMultipartUploadResult>", name)
+
+	case r.Method == http.MethodDelete:
+		delete(f.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestMultipartUpload(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	u, err := client.CreateMultipartUpload(ctx, "logs", "segment", &CreateMultipartUploadInput{ChecksumSHA256: true})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+
+	// Upload out of order; completion must list parts in order.
+	for _, n := range []int{2, 1} {
+		part, err := client.UploadPart(ctx, u, n, strings.NewReader(fmt.Sprintf("part%d;", n)))
+		if err != nil {
+			t.Fatalf("UploadPart: %v", err)
+		}
+		if part.ETag == "" || part.ChecksumSHA256 == "" {
+			t.Errorf("expected ETag and checksum, got %+v", part)
+		}
+	}
+
+	for _, n := range []int{0, 10001} {
+		if _, err := client.UploadPart(ctx, u, n, strings.NewReader("part")); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("UploadPart(%d): expected ErrInvalidArgument, got %v", n, err)
+		}
+	}
+
+	listed, err := client.ListParts(ctx, "logs", "segment", u.UploadID, nil)
+	if err != nil {
+		t.Fatalf("ListParts: %v", err)
+	}
+	if len(listed.Parts) != 2 || listed.Parts[0].Size != 6 {
+		t.Errorf("unexpected parts %+v", listed.Parts)
+	}
+
+	out, err := client.CompleteMultipartUpload(ctx, u)
+	if err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	if out.ETag != `"complete"` {
+		t.Errorf("unexpected ETag %s", out.ETag)
+	}
+
+	get, err := client.GetObject(ctx, "logs", "segment", nil)
+	if err != nil {
+		t.Fatalf("GetObject: %v", err)
+	}
+	data, _ := io.ReadAll(get.Body)
+	get.Body.Close()
+	if string(data) != "part1;part2;" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestCompleteMultipartUploadErrorBody(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	u, err := client.CreateMultipartUpload(ctx, "logs", "segment", nil)
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+	u.AddPart(CompletedPart{PartNumber: 1, ETag: `"bogus"`})
+
+	_, err = client.CompleteMultipartUpload(ctx, u)
+	if ErrorCode(err) != "InvalidPart" {
+		t.Errorf("expected InvalidPart, got %v", err)
+	}
+
+	if err := client.AbortMultipartUpload(ctx, u); err != nil {
+		t.Fatalf("AbortMultipartUpload: %v", err)
+	}
+	if _, err := client.ListParts(ctx, "logs", "segment", u.UploadID, nil); !IsNotFound(err) {
+		t.Errorf("expected NoSuchUpload, got %v", err)
+	}
+}
+
+func TestPresignUploadParts(t *testing.T) {
+	client := newTestClient(t)
+	ctx := context.Background()
+
+	u, err := client.CreateMultipartUpload(ctx, "logs", "segment", nil)
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+
+	presigned, err := client.PresignUploadParts(ctx, u, []int{1, 2}, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("PresignUploadParts: %v", err)
+	}
+	if len(presigned) != 2 {
+		t.Fatalf("expected 2 presigned parts, got %d", len(presigned))
+	}
+
+	for _, p := range presigned {
+		parsed, err := url.Parse(p.URL)
+		if err != nil {
+			t.Fatalf("failed to parse URL: %v", err)
+		}
+		q := parsed.Query()
+		if q.Get("partNumber") != strconv.Itoa(p.PartNumber) || q.Get("uploadId") != u.UploadID {
+			t.Errorf("unexpected part query %s", parsed.RawQuery)
+		}
+		if q.Get(signer.AmzExpiresKey) != "900" || q.Get(signer.AmzSignatureKey) == "" {
+			t.Errorf("unexpected presign query %s", parsed.RawQuery)
+		}
+
+		// Upload as a browser would, without the client or signer.
+		req, _ := http.NewRequest(http.MethodPut, p.URL, bytes.NewReader([]byte(fmt.Sprintf("p%d", p.PartNumber))))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("upload failed: %v", err)
+		}
+		resp.Body.Close()
+		u.AddPart(CompletedPart{PartNumber: p.PartNumber, ETag: resp.Header.Get("ETag")})
+	}
+
+	if _, err := client.CompleteMultipartUpload(ctx, u); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+
+	if _, err := client.PresignUploadParts(ctx, u, []int{1}, 8*24*time.Hour); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for expiry over 7 days, got %v", err)
+	}
+	if _, err := client.PresignUploadParts(ctx, u, []int{1}, 500*time.Millisecond); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for sub-second expiry, got %v", err)
+	}
+	if _, err := client.PresignUploadParts(ctx, u, []int{1, 10001}, time.Hour); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for part number 10001, got %v", err)
+	}
+	empty := &MultipartUpload{Bucket: u.Bucket, Key: u.Key, UploadID: u.UploadID}
+	if _, err := client.CompleteMultipartUpload(ctx, empty); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("expected ErrInvalidArgument for upload without parts, got %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+package s3client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// metaPrefix is the header prefix for user-defined object metadata.
+const metaPrefix = "X-Amz-Meta-"
+
+// Conditions are the conditional request headers supported by S3.
+// Zero values are not sent.
+type Conditions struct {
+	IfMatch           string
+	IfNoneMatch       string
+	IfModifiedSince   time.Time
+	IfUnmodifiedSince time.Time
+}
+
+// apply sets the conditional headers on h.
+func (c *Conditions) apply(h http.Header) {
+	if c == nil {
+		return
+	}
+	if c.IfMatch != "" {
+		h.Set("If-Match", c.IfMatch)
+	}
+	if c.IfNoneMatch != "" {
+		h.Set("If-None-Match", c.IfNoneMatch)
+	}
+	if !c.IfModifiedSince.IsZero() {
+		h.Set("If-Modified-Since", c.IfModifiedSince.UTC().Format(http.TimeFormat))
+	}
+	if !c.IfUnmodifiedSince.IsZero() {
+		h.Set("If-Unmodified-Since", c.IfUnmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+}
+
+// ObjectInfo is the object metadata returned by GetObject and HeadObject.
+type ObjectInfo struct {
+	ContentLength int64
+	ContentType   string
+	ETag          string
+	LastModified  time.Time
+	VersionID     string
+
+	// Metadata holds user-defined metadata keyed by lower-cased name
+	// without the x-amz-meta- prefix.
+	Metadata map[string]string
+}
+
+// objectInfo reads object metadata from response headers.
+func objectInfo(resp *http.Response) ObjectInfo {
+	info := ObjectInfo{
+		ContentLength: resp.ContentLength,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ETag:          resp.Header.Get("ETag"),
+		VersionID:     resp.Header.Get("X-Amz-Version-Id"),
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = t
+	}
+	for k, v := range resp.Header {
+		if strings.HasPrefix(k, metaPrefix) && len(v) > 0 {
+			if info.Metadata == nil {
+				info.Metadata = make(map[string]string)
+			}
+			info.Metadata[strings.ToLower(k[len(metaPrefix):])] = v[0]
+		}
+	}
+	return info
+}
+
+// GetObjectInput holds the optional parameters of GetObject.
+type GetObjectInput struct {
+	Conditions
+
+	// Range is an HTTP Range header value, e.g. "bytes=0-1023".
+	Range string
+}
+
+// GetObjectOutput is the result of GetObject.
+// The caller must close Body.
+type GetObjectOutput struct {
+	ObjectInfo
+	ContentRange string
+	Body         io.ReadCloser
+}
+
+// GetObject retrieves an object. A failed precondition is reported as an
+// *Error with code "PreconditionFailed" or "NotModified".
+func (c *Client) GetObject(ctx context.Context, bucket, key string, in *GetObjectInput) (*GetObjectOutput, error) {
+	header := make(http.Header)
+	if in != nil {
+		in.Conditions.apply(header)
+		if in.Range != "" {
+			header.Set("Range", in.Range)
+		}
+	}
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodGet,
+		bucket: bucket,
+		key:    key,
+		header: header,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetObjectOutput{
+		ObjectInfo:   objectInfo(resp),
+		ContentRange: resp.Header.Get("Content-Range"),
+		Body:         resp.Body,
+	}, nil
+}
+
+// HeadObject retrieves object metadata without the body.
+func (c *Client) HeadObject(ctx context.Context, bucket, key string, cond *Conditions) (*ObjectInfo, error) {
+	header := make(http.Header)
+	cond.apply(header)
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodHead,
+		bucket: bucket,
+		key:    key,
+		header: header,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	info := objectInfo(resp)
+	return &info, nil
+}
+
+// PutObjectInput holds the optional parameters of PutObject.
+type PutObjectInput struct {
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+
+	// Metadata is sent as x-amz-meta-* headers.
+	Metadata map[string]string
+
+	// IfMatch and IfNoneMatch make the write conditional. Use
+	// IfNoneMatch "*" to only create objects that do not exist.
+	IfMatch     string
+	IfNoneMatch string
+}
+
+// PutObjectOutput is the result of PutObject.
+type PutObjectOutput struct {
+	ETag      string
+	VersionID string
+}
+
+// PutObject uploads body as the content of an object. The body is read
+// once to compute the payload hash and then rewound and sent.
+func (c *Client) PutObject(ctx context.Context, bucket, key string, body io.ReadSeeker, in *PutObjectInput) (*PutObjectOutput, error) {
+	header := make(http.Header)
+	if in != nil {
+		setIfNotEmpty(header, "Content-Type", in.ContentType)
+		setIfNotEmpty(header, "Cache-Control", in.CacheControl)
+		setIfNotEmpty(header, "Content-Disposition", in.ContentDisposition)
+		setIfNotEmpty(header, "Content-Encoding", in.ContentEncoding)
+		setIfNotEmpty(header, "If-Match", in.IfMatch)
+		setIfNotEmpty(header, "If-None-Match", in.IfNoneMatch)
+		for k, v := range in.Metadata {
+			header.Set(metaPrefix+k, v)
+		}
+	}
+
+	resp, err := c.do(ctx, request{
+		method: http.MethodPut,
+		bucket: bucket,
+		key:    key,
+		header: header,
+		body:   body,
+	})
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	return &PutObjectOutput{
+		ETag:      resp.Header.Get("ETag"),
+		VersionID: resp.Header.Get("X-Amz-Version-Id"),
+	}, nil
+}
+
+// DeleteObject deletes an object. S3 reports success for keys that do not
+// exist.
+func (c *Client) DeleteObject(ctx context.Context, bucket, key string) error {
+	resp, err := c.do(ctx, request{
+		method: http.MethodDelete,
+		bucket: bucket,
+		key:    key,
+	})
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// setIfNotEmpty sets a header only when value is non-empty.
+func setIfNotEmpty(h http.Header, key, value string) {
+	if value != "" {
+		h.Set(key, value)
+	}
+}
@@ -0,0 +1,195 @@
+package s3test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// upload is an in-progress multipart upload.
+type upload struct {
+	bucket string
+	key    string
+	header http.Header
+	parts  map[int]*part
+}
+
+// part is an uploaded part of a multipart upload.
+type part struct {
+	data         []byte
+	etag         string
+	checksum     string
+	lastModified time.Time
+}
+
+// createMultipartUpload serves CreateMultipartUpload.
+func (s *Server) createMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, key string) *s3Error {
+	if _, ok := s.buckets[bucketName]; !ok {
+		return errNoSuchBucket
+	}
+
+	uploadID := fmt.Sprintf("upload-%d", s.requestID)
+	s.uploads[uploadID] = &upload{
+		bucket: bucketName,
+		key:    key,
+		header: r.Header.Clone(),
+		parts:  make(map[int]*part),
+	}
+
+	result := struct {
+		XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+		Bucket   string   `xml:"Bucket"`
+		Key      string   `xml:"Key"`
+		UploadID string   `xml:"UploadId"`
+	}{Bucket: bucketName, Key: key, UploadID: uploadID}
+
+	w.Header().Set("Content-Type", "application/xml")
+	writeXML(w, result)
+	return nil
+}
+
+// multipart serves UploadPart, CompleteMultipartUpload,
+// AbortMultipartUpload and ListParts.
+func (s *Server) multipart(w http.ResponseWriter, r *http.Request, b *bucket, bucketName, key string, body []byte) *s3Error {
+	uploadID := r.URL.Query().Get("uploadId")
+	u, ok := s.uploads[uploadID]
+	if !ok || u.bucket != bucketName || u.key != key {
+		return errNoSuchUpload
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		return u.uploadPart(w, r, body, s.Now())
+	case http.MethodPost:
+		err := u.complete(w, b, body, s.Now())
+		if err == nil {
+			delete(s.uploads, uploadID)
+		}
+		return err
+	case http.MethodDelete:
+		delete(s.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	case http.MethodGet:
+		return u.listParts(w, uploadID)
+	}
+	return newError(http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+}
+
+// uploadPart stores one part, checking any x-amz-checksum-sha256 header.
+func (u *upload) uploadPart(w http.ResponseWriter, r *http.Request, body []byte, now time.Time) *s3Error {
+	n, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || n < 1 || n > 10000 {
+		return newError(http.StatusBadRequest, "InvalidArgument", "Part number must be an integer between 1 and 10000, inclusive")
+	}
+
+	sum := sha256.Sum256(body)
+	p := &part{
+		data:         body,
+		etag:         md5ETag(body),
+		checksum:     base64.StdEncoding.EncodeToString(sum[:]),
+		lastModified: now.UTC().Truncate(time.Second),
+	}
+	if c := r.Header.Get("X-Amz-Checksum-Sha256"); c != "" {
+		if c != p.checksum {
+			return newError(http.StatusBadRequest, "BadDigest", "The SHA256 you specified did not match the calculated checksum.")
+		}
+		w.Header().Set("X-Amz-Checksum-Sha256", c)
+	}
+
+	u.parts[n] = p
+	w.Header().Set("ETag", p.etag)
+	return nil
+}
+
+// complete assembles the listed parts into an object. As S3 does, an
+// invalid part list is reported with a 200 status and an Error document.
+// Reference: Amazon S3 API Reference, "CompleteMultipartUpload"
+func (u *upload) complete(w http.ResponseWriter, b *bucket, body []byte, now time.Time) *s3Error {
+	var doc struct {
+		Parts []struct {
+			PartNumber     int    `xml:"PartNumber"`
+			ETag           string `xml:"ETag"`
+			ChecksumSHA256 string `xml:"ChecksumSHA256"`
+		} `xml:"Part"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil || len(doc.Parts) == 0 {
+		return newError(http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.")
+	}
+
+	var data, digests []byte
+	for i, listed := range doc.Parts {
+		if i > 0 && listed.PartNumber <= doc.Parts[i-1].PartNumber {
+			return newError(http.StatusOK, "InvalidPartOrder", "The list of parts was not in ascending order.")
+		}
+		p, ok := u.parts[listed.PartNumber]
+		if !ok || p.etag != listed.ETag || (listed.ChecksumSHA256 != "" && listed.ChecksumSHA256 != p.checksum) {
+			return newError(http.StatusOK, "InvalidPart", "One or more of the specified parts could not be found.")
+		}
+		data = append(data, p.data...)
+		digest, _ := hex.DecodeString(p.etag[1 : len(p.etag)-1])
+		digests = append(digests, digest...)
+	}
+
+	sum := md5.Sum(digests)
+	etag := fmt.Sprintf(`"%s-%d"`, hex.EncodeToString(sum[:]), len(doc.Parts))
+	b.objects[u.key] = newObject(data, etag, u.header, now)
+
+	result := struct {
+		XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+		Bucket  string   `xml:"Bucket"`
+		Key     string   `xml:"Key"`
+		ETag    string   `xml:"ETag"`
+	}{Bucket: u.bucket, Key: u.key, ETag: etag}
+
+	w.Header().Set("Content-Type", "application/xml")
+	writeXML(w, result)
+	return nil
+}
+
+// listParts serves ListParts, returning every part in a single page.
+func (u *upload) listParts(w http.ResponseWriter, uploadID string) *s3Error {
+	type listedPart struct {
+		PartNumber     int    `xml:"PartNumber"`
+		LastModified   string `xml:"LastModified"`
+		ETag           string `xml:"ETag"`
+		Size           int    `xml:"Size"`
+		ChecksumSHA256 string `xml:"ChecksumSHA256"`
+	}
+	result := struct {
+		XMLName     xml.Name     `xml:"ListPartsResult"`
+		Bucket      string       `xml:"Bucket"`
+		Key         string       `xml:"Key"`
+		UploadID    string       `xml:"UploadId"`
+		MaxParts    int          `xml:"MaxParts"`
+		IsTruncated bool         `xml:"IsTruncated"`
+		Parts       []listedPart `xml:"Part"`
+	}{Bucket: u.bucket, Key: u.key, UploadID: uploadID, MaxParts: 1000}
+
+	numbers := make([]int, 0, len(u.parts))
+	for n := range u.parts {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		p := u.parts[n]
+		result.Parts = append(result.Parts, listedPart{
+			PartNumber:     n,
+			LastModified:   p.lastModified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:           p.etag,
+			Size:           len(p.data),
+			ChecksumSHA256: p.checksum,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	writeXML(w, result)
+	return nil
+}
@@ -0,0 +1,280 @@
+package s3test
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bucket holds the objects of one bucket.
+type bucket struct {
+	objects map[string]*object
+}
+
+func newBucket() *bucket {
+	return &bucket{objects: make(map[string]*object)}
+}
+
+// object is a stored object with the headers returned when it is read.
+type object struct {
+	data         []byte
+	etag         string
+	lastModified time.Time
+	header       http.Header
+}
+
+// storedHeaders are request headers kept with an object and returned by
+// GetObject and HeadObject.
+var storedHeaders = []string{
+	"Cache-Control",
+	"Content-Disposition",
+	"Content-Encoding",
+	"Content-Type",
+}
+
+// newObject creates an object from a PUT or completed upload, keeping its
+// content headers and user metadata.
+func newObject(data []byte, etag string, header http.Header, now time.Time) *object {
+	obj := &object{
+		data:         data,
+		etag:         etag,
+		lastModified: now.UTC().Truncate(time.Second),
+		header:       make(http.Header),
+	}
+	for _, k := range storedHeaders {
+		if v := header.Get(k); v != "" {
+			obj.header.Set(k, v)
+		}
+	}
+	for k, v := range header {
+		if strings.HasPrefix(k, "X-Amz-Meta-") {
+			obj.header[k] = v
+		}
+	}
+	return obj
+}
+
+// md5ETag returns the quoted hex MD5 ETag S3 uses for single-part objects.
+func md5ETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// checkConditions evaluates If-Match and If-None-Match against obj, which
+// is nil when the object does not exist.
+func checkConditions(r *http.Request, obj *object) *s3Error {
+	if m := r.Header.Get("If-Match"); m != "" {
+		if obj == nil {
+			return errNoSuchKey
+		}
+		if m != "*" && m != obj.etag {
+			return errPreconditionFailed
+		}
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" && obj != nil && (m == "*" || m == obj.etag) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			return &s3Error{Status: http.StatusNotModified, Code: "NotModified", Message: "Not Modified"}
+		}
+		return errPreconditionFailed
+	}
+	return nil
+}
+
+// putObject stores an object, checking any Content-MD5 and conditional
+// headers.
+func (b *bucket) putObject(w http.ResponseWriter, r *http.Request, key string, body []byte, now time.Time) *s3Error {
+	if err := checkConditions(r, b.objects[key]); err != nil {
+		return err
+	}
+	if m := r.Header.Get("Content-Md5"); m != "" {
+		sum := md5.Sum(body)
+		if m != base64.StdEncoding.EncodeToString(sum[:]) {
+			return newError(http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what we received.")
+		}
+	}
+
+	obj := newObject(body, md5ETag(body), r.Header, now)
+	b.objects[key] = obj
+	w.Header().Set("ETag", obj.etag)
+	return nil
+}
+
+// getObject serves GetObject and HeadObject, including single byte
+// ranges of the form "bytes=first-last".
+func (b *bucket) getObject(w http.ResponseWriter, r *http.Request, key string) *s3Error {
+	obj := b.objects[key]
+	if err := checkConditions(r, obj); err != nil {
+		return err
+	}
+	if obj == nil {
+		return errNoSuchKey
+	}
+
+	h := w.Header()
+	for k, v := range obj.header {
+		h[k] = v
+	}
+	h.Set("ETag", obj.etag)
+	h.Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+	h.Set("Accept-Ranges", "bytes")
+
+	data, status := obj.data, http.StatusOK
+	if rng := r.Header.Get("Range"); rng != "" {
+		first, last, ok := parseRange(rng, int64(len(obj.data)))
+		if !ok {
+			return newError(http.StatusRequestedRangeNotSatisfiable, "InvalidRange", "The requested range is not satisfiable")
+		}
+		h.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", first, last, len(obj.data)))
+		data, status = obj.data[first:last+1], http.StatusPartialContent
+	}
+
+	h.Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodGet {
+		w.Write(data)
+	}
+	return nil
+}
+
+// parseRange parses a single "bytes=first-last" or "bytes=first-" range.
+func parseRange(value string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(value, "bytes=")
+	if !ok {
+		return 0, 0, false
+	}
+	firstStr, lastStr, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(firstStr, 10, 64)
+	if err != nil || first >= size {
+		return 0, 0, false
+	}
+	last := size - 1
+	if lastStr != "" {
+		if last, err = strconv.ParseInt(lastStr, 10, 64); err != nil || last < first {
+			return 0, 0, false
+		}
+		if last >= size {
+			last = size - 1
+		}
+	}
+	return first, last, true
+}
+
+// listObjectsV2 serves ListObjectsV2 with prefix, delimiter, start-after,
+// max-keys and continuation tokens.
+// Reference: Amazon S3 API Reference, "ListObjectsV2"
+func (b *bucket) listObjectsV2(w http.ResponseWriter, name string, query url.Values) *s3Error {
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int    `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		KeyCount              int            `xml:"KeyCount"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		StartAfter            string         `xml:"StartAfter,omitempty"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{
+		Name:              name,
+		Prefix:            query.Get("prefix"),
+		Delimiter:         query.Get("delimiter"),
+		MaxKeys:           1000,
+		ContinuationToken: query.Get("continuation-token"),
+		StartAfter:        query.Get("start-after"),
+	}
+
+	if v := query.Get("max-keys"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return newError(http.StatusBadRequest, "InvalidArgument", "Provided max-keys not an integer or within integer range")
+		}
+		if n < result.MaxKeys {
+			result.MaxKeys = n
+		}
+	}
+
+	after := result.StartAfter
+	if result.ContinuationToken != "" {
+		token, err := base64.RawURLEncoding.DecodeString(result.ContinuationToken)
+		if err != nil {
+			return newError(http.StatusBadRequest, "InvalidArgument", "The continuation token provided is incorrect")
+		}
+		after = string(token)
+	}
+
+	keys := make([]string, 0, len(b.objects))
+	for k := range b.objects {
+		if strings.HasPrefix(k, result.Prefix) && k > after {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]bool)
+	last := ""
+	for _, k := range keys {
+		// Keys rolled up into a prefix already listed do not count.
+		p := ""
+		if result.Delimiter != "" {
+			rest := k[len(result.Prefix):]
+			if i := strings.Index(rest, result.Delimiter); i >= 0 {
+				p = result.Prefix + rest[:i+len(result.Delimiter)]
+			}
+		}
+		if p != "" && seen[p] {
+			last = k
+			continue
+		}
+		if result.KeyCount == result.MaxKeys {
+			result.IsTruncated = true
+			break
+		}
+		if p != "" {
+			seen[p] = true
+			result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{p})
+			result.KeyCount++
+			last = k
+			continue
+		}
+		obj := b.objects[k]
+		result.Contents = append(result.Contents, content{
+			Key:          k,
+			LastModified: obj.lastModified.Format("2006-01-02T15:04:05.000Z"),
+			ETag:         obj.etag,
+			Size:         len(obj.data),
+			StorageClass: "STANDARD",
+		})
+		result.KeyCount++
+		last = k
+	}
+	if result.IsTruncated {
+		result.NextContinuationToken = base64.RawURLEncoding.EncodeToString([]byte(last))
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	writeXML(w, result)
+	return nil
+}
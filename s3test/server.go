@@ -0,0 +1,246 @@
+// Package s3test provides an in-memory S3-compatible server for testing
+// code that signs requests with the signer package, without network
+// access. Every request must carry a valid SigV4 Authorization header or
+// presigned query string, checked with signer.Verifier, and failures are
+// reported with realistic S3 XML error responses.
+//
+// Buckets are addressed path-style, as signer.S3Endpoint does for the
+// 127.0.0.1 address the server listens on.
+package s3test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+// Server is a fake S3 server backed by memory.
+type Server struct {
+	// URL is the base URL of the server, for use as an S3 endpoint.
+	URL string
+
+	// Now returns the time requests are verified at. It defaults to
+	// time.Now and must be set before the server is used.
+	Now func() time.Time
+
+	server   *httptest.Server
+	verifier *signer.Verifier
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	uploads   map[string]*upload
+	requestID int
+}
+
+// NewServer starts a server that accepts requests signed with the
+// credentials, region and service in config.
+func NewServer(config signer.Config) (*Server, error) {
+	config.ThreadSafety = true
+	verifier, err := signer.NewVerifier(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Now:      time.Now,
+		verifier: verifier,
+		buckets:  make(map[string]*bucket),
+		uploads:  make(map[string]*upload),
+	}
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s, nil
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// CreateBucket creates an empty bucket if it does not already exist.
+func (s *Server) CreateBucket(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[name]; !ok {
+		s.buckets[name] = newBucket()
+	}
+}
+
+// Object returns a copy of the content of an object.
+func (s *Server) Object(bucketName, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, false
+	}
+	obj, ok := b.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte(nil), obj.data...), true
+}
+
+// s3Error is an S3 error response.
+// Reference: Amazon S3 API Reference, "Error responses"
+type s3Error struct {
+	XMLName   xml.Name `xml:"Error"`
+	Status    int      `xml:"-"`
+	Code      string   `xml:"Code"`
+	Message   string   `xml:"Message"`
+	Resource  string   `xml:"Resource,omitempty"`
+	RequestID string   `xml:"RequestId"`
+}
+
+func newError(status int, code, message string) *s3Error {
+	return &s3Error{Status: status, Code: code, Message: message}
+}
+
+// Common S3 errors.
+var (
+	errNoSuchBucket       = newError(http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist.")
+	errNoSuchKey          = newError(http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+	errNoSuchUpload       = newError(http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist.")
+	errPreconditionFailed = newError(http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold.")
+	errNotImplemented     = newError(http.StatusNotImplemented, "NotImplemented", "A header or query you provided implies functionality that is not implemented.")
+)
+
+// ServeHTTP authenticates and dispatches a request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requestID++
+	requestID := fmt.Sprintf("%016X", s.requestID)
+	w.Header().Set("X-Amz-Request-Id", requestID)
+
+	body, err := s.authenticate(r)
+	if err == nil {
+		err = s.route(w, r, body)
+	}
+	if err != nil {
+		e := *err
+		e.Resource = r.URL.Path
+		e.RequestID = requestID
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(e.Status)
+		if r.Method != http.MethodHead {
+			writeXML(w, e)
+		}
+	}
+}
+
+// authenticate verifies the request signature and reads the body,
+// checking it against the declared payload hash.
+func (s *Server) authenticate(r *http.Request) ([]byte, *s3Error) {
+	now := s.Now()
+	payloadHash := r.Header.Get(signer.ContentSHAKey)
+
+	var err error
+	switch {
+	case signer.IsPresigned(r):
+		if payloadHash == "" {
+			payloadHash = signer.UnsignedPayload
+		}
+		err = s.verifier.VerifyPresignedHTTP(r, payloadHash, now)
+	case r.Header.Get(signer.AuthorizationHeader) == "":
+		return nil, newError(http.StatusForbidden, "AccessDenied", "Access Denied")
+	case payloadHash == "":
+		return nil, newError(http.StatusBadRequest, "InvalidRequest", "Missing required header for this request: x-amz-content-sha256")
+	default:
+		err = s.verifier.VerifyHTTP(r, payloadHash, now)
+	}
+	if err != nil {
+		return nil, newError(http.StatusForbidden, "SignatureDoesNotMatch", err.Error())
+	}
+
+	body, readErr := io.ReadAll(r.Body)
+	if readErr != nil {
+		return nil, newError(http.StatusBadRequest, "IncompleteBody", readErr.Error())
+	}
+	if payloadHash != signer.UnsignedPayload {
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != payloadHash {
+			return nil, newError(http.StatusBadRequest, "XAmzContentSHA256Mismatch", "The provided 'x-amz-content-sha256' header does not match what was computed.")
+		}
+	}
+	return body, nil
+}
+
+// route dispatches a path-style request to its handler.
+func (s *Server) route(w http.ResponseWriter, r *http.Request, body []byte) *s3Error {
+	bucketName, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucketName == "" {
+		return errNotImplemented
+	}
+	query := r.URL.Query()
+
+	if key == "" {
+		switch r.Method {
+		case http.MethodPut:
+			if _, ok := s.buckets[bucketName]; ok {
+				return newError(http.StatusConflict, "BucketAlreadyOwnedByYou", "Your previous request to create the named bucket succeeded and you already own it.")
+			}
+			s.buckets[bucketName] = newBucket()
+			return nil
+		}
+	}
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return errNoSuchBucket
+	}
+
+	if key == "" {
+		switch {
+		case r.Method == http.MethodHead:
+			return nil
+		case r.Method == http.MethodDelete:
+			if len(b.objects) > 0 {
+				return newError(http.StatusConflict, "BucketNotEmpty", "The bucket you tried to delete is not empty.")
+			}
+			delete(s.buckets, bucketName)
+			w.WriteHeader(http.StatusNoContent)
+			return nil
+		case r.Method == http.MethodGet && query.Get("list-type") == "2":
+			return b.listObjectsV2(w, bucketName, query)
+		}
+		return errNotImplemented
+	}
+
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		return s.createMultipartUpload(w, r, bucketName, key)
+	case query.Has("uploadId"):
+		return s.multipart(w, r, b, bucketName, key, body)
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		return b.putObject(w, r, key, body, s.Now())
+	case http.MethodGet, http.MethodHead:
+		return b.getObject(w, r, key)
+	case http.MethodDelete:
+		delete(b.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return newError(http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+}
+
+// writeXML writes v as an XML document.
+func writeXML(w io.Writer, v interface{}) {
+	io.WriteString(w, xml.Header)
+	xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,252 @@
+package s3test_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forestrie/go-sigv4/s3client"
+	"github.com/forestrie/go-sigv4/s3test"
+	"github.com/forestrie/go-sigv4/signer"
+)
+
+var testConfig = signer.Config{
+	Region:          "auto",
+	AccessKeyID:     "AKID",
+	SecretAccessKey: "SECRET",
+	ThreadSafety:    true,
+}
+
+func newServer(t *testing.T) (*s3test.Server, *signer.Signer) {
+	t.Helper()
+
+	server, err := s3test.NewServer(testConfig)
+	if err != nil {
+		t.Fatalf("failed to start server: %v", err)
+	}
+	t.Cleanup(server.Close)
+	server.CreateBucket("logs")
+
+	s, err := signer.NewSigner(testConfig)
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	return server, s
+}
+
+// send signs req with SignHTTP and returns the response body.
+func send(t *testing.T, s *signer.Signer, req *http.Request, body []byte) (*http.Response, []byte) {
+	t.Helper()
+
+	payloadHash, err := signer.ComputePayloadHash(bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("failed to hash body: %v", err)
+	}
+	req.Header.Set(signer.ContentSHAKey, payloadHash)
+	if err := s.SignHTTP(req, payloadHash, time.Now()); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	return do(t, req)
+}
+
+func do(t *testing.T, req *http.Request) (*http.Response, []byte) {
+	t.Helper()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp, data
+}
+
+func errorCode(t *testing.T, body []byte) string {
+	t.Helper()
+
+	var e struct {
+		Code string `xml:"Code"`
+	}
+	if err := xml.Unmarshal(body, &e); err != nil {
+		t.Fatalf("failed to decode error %q: %v", body, err)
+	}
+	return e.Code
+}
+
+func TestSignHTTPEndToEnd(t *testing.T) {
+	server, s := newServer(t)
+
+	body := []byte("segment data")
+	req, _ := http.NewRequest("PUT", server.URL+"/logs/2023/a%20b+c.log", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Amz-Meta-Source", "ingest")
+	resp, data := send(t, s, req, body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, data)
+	}
+
+	stored, ok := server.Object("logs", "2023/a b+c.log")
+	if !ok || !bytes.Equal(stored, body) {
+		t.Fatalf("expected stored object, got %q", stored)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"/logs/2023/a%20b+c.log", nil)
+	resp, data = send(t, s, req, nil)
+	if resp.StatusCode != http.StatusOK || !bytes.Equal(data, body) {
+		t.Errorf("unexpected GET response %d: %q", resp.StatusCode, data)
+	}
+	if resp.Header.Get("X-Amz-Meta-Source") != "ingest" {
+		t.Error("expected metadata to be returned")
+	}
+}
+
+func TestSignHTTPRejected(t *testing.T) {
+	server, s := newServer(t)
+
+	// Signed with the wrong secret.
+	other := testConfig
+	other.SecretAccessKey = "OTHER"
+	wrong, _ := signer.NewSigner(other)
+	req, _ := http.NewRequest("GET", server.URL+"/logs/key", nil)
+	resp, data := send(t, wrong, req, nil)
+	if resp.StatusCode != http.StatusForbidden || errorCode(t, data) != "SignatureDoesNotMatch" {
+		t.Errorf("expected SignatureDoesNotMatch, got %d: %s", resp.StatusCode, data)
+	}
+
+	// Body differs from the signed payload hash.
+	req, _ = http.NewRequest("PUT", server.URL+"/logs/key", strings.NewReader("tampered"))
+	req.Header.Set(signer.ContentSHAKey, signer.EmptyStringSHA256)
+	s.SignHTTP(req, signer.EmptyStringSHA256, time.Now())
+	resp, data = do(t, req)
+	if errorCode(t, data) != "XAmzContentSHA256Mismatch" {
+		t.Errorf("expected XAmzContentSHA256Mismatch, got %d: %s", resp.StatusCode, data)
+	}
+
+	// Unsigned.
+	req, _ = http.NewRequest("GET", server.URL+"/logs/key", nil)
+	resp, data = do(t, req)
+	if resp.StatusCode != http.StatusForbidden || errorCode(t, data) != "AccessDenied" {
+		t.Errorf("expected AccessDenied, got %d: %s", resp.StatusCode, data)
+	}
+
+	// Missing object.
+	req, _ = http.NewRequest("GET", server.URL+"/logs/missing", nil)
+	resp, data = send(t, s, req, nil)
+	if resp.StatusCode != http.StatusNotFound || errorCode(t, data) != "NoSuchKey" {
+		t.Errorf("expected NoSuchKey, got %d: %s", resp.StatusCode, data)
+	}
+}
+
+func TestPresignHTTPEndToEnd(t *testing.T) {
+	server, s := newServer(t)
+
+	req, _ := http.NewRequest("PUT", server.URL+"/logs/upload.bin?X-Amz-Expires=300", nil)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	signedURL, signedHeaders, err := s.PresignHTTP(req, signer.UnsignedPayload, time.Now())
+	if err != nil {
+		t.Fatalf("failed to presign: %v", err)
+	}
+
+	put, _ := http.NewRequest("PUT", signedURL, strings.NewReader("presigned"))
+	for k, v := range signedHeaders {
+		if k != "Host" {
+			put.Header[k] = v
+		}
+	}
+	resp, data := do(t, put)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, data)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL+"/logs/upload.bin?X-Amz-Expires=60", nil)
+	signedURL, _, err = s.PresignHTTP(req, signer.UnsignedPayload, time.Now())
+	if err != nil {
+		t.Fatalf("failed to presign: %v", err)
+	}
+	resp, data = do(t, mustRequest("GET", signedURL))
+	if resp.StatusCode != http.StatusOK || string(data) != "presigned" {
+		t.Errorf("unexpected GET response %d: %q", resp.StatusCode, data)
+	}
+
+	// An expired URL is rejected.
+	signedURL, _, _ = s.PresignHTTP(req, signer.UnsignedPayload, time.Now().Add(-time.Hour))
+	resp, data = do(t, mustRequest("GET", signedURL))
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected 403 for expired URL, got %d: %s", resp.StatusCode, data)
+	}
+
+	// A tampered URL is rejected.
+	signedURL, _, _ = s.PresignHTTP(req, signer.UnsignedPayload, time.Now())
+	resp, data = do(t, mustRequest("GET", strings.Replace(signedURL, "upload.bin", "other.bin", 1)))
+	if resp.StatusCode != http.StatusForbidden || errorCode(t, data) != "SignatureDoesNotMatch" {
+		t.Errorf("expected SignatureDoesNotMatch, got %d: %s", resp.StatusCode, data)
+	}
+}
+
+func mustRequest(method, url string) *http.Request {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		panic(err)
+	}
+	return req
+}
+
+func TestClientEndToEnd(t *testing.T) {
+	server, s := newServer(t)
+	ctx := context.Background()
+
+	client, err := s3client.New(s, s3client.Options{Endpoint: server.URL})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	for _, key := range []string{"a/1", "a/2", "b/1", "c"} {
+		if _, err := client.PutObject(ctx, "logs", key, strings.NewReader(key), nil); err != nil {
+			t.Fatalf("PutObject: %v", err)
+		}
+	}
+
+	out, err := client.ListObjectsV2(ctx, "logs", &s3client.ListObjectsV2Input{Delimiter: "/", MaxKeys: 2})
+	if err != nil {
+		t.Fatalf("ListObjectsV2: %v", err)
+	}
+	if len(out.CommonPrefixes) != 2 || !out.IsTruncated {
+		t.Fatalf("unexpected first page %+v", out)
+	}
+	out, err = client.ListObjectsV2(ctx, "logs", &s3client.ListObjectsV2Input{
+		Delimiter:         "/",
+		ContinuationToken: out.NextContinuationToken,
+	})
+	if err != nil {
+		t.Fatalf("ListObjectsV2: %v", err)
+	}
+	if len(out.Contents) != 1 || out.Contents[0].Key != "c" || out.IsTruncated {
+		t.Errorf("unexpected second page %+v", out)
+	}
+
+	u, err := client.CreateMultipartUpload(ctx, "logs", "big", &s3client.CreateMultipartUploadInput{ChecksumSHA256: true})
+	if err != nil {
+		t.Fatalf("CreateMultipartUpload: %v", err)
+	}
+	for n, data := range []string{"one,", "two"} {
+		if _, err := client.UploadPart(ctx, u, n+1, strings.NewReader(data)); err != nil {
+			t.Fatalf("UploadPart: %v", err)
+		}
+	}
+	if _, err := client.CompleteMultipartUpload(ctx, u); err != nil {
+		t.Fatalf("CompleteMultipartUpload: %v", err)
+	}
+	if data, _ := server.Object("logs", "big"); string(data) != "one,two" {
+		t.Errorf("unexpected multipart object %q", data)
+	}
+
+	_, err = client.GetObject(ctx, "missing", "key", nil)
+	if s3client.ErrorCode(err) != "NoSuchBucket" {
+		t.Errorf("expected NoSuchBucket, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package signer
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var benchTime = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// newBenchRequest returns a typical S3 PutObject request.
+func newBenchRequest() *http.Request {
+	req, _ := http.NewRequest(http.MethodPut, "https://bucket.s3.us-east-1.amazonaws.com/path/to/object.txt", nil)
+	req.ContentLength = 1024
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("User-Agent", "go-sigv4")
+	req.Header.Set(ContentSHAKey, EmptyStringSHA256)
+	req.Header.Set("X-Amz-Meta-Owner", "forestrie")
+	return req
+}
+
+func newBenchSigner(b *testing.B, threadSafe bool) *Signer {
+	s, err := NewSigner(Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Service:         "s3",
+		ThreadSafety:    threadSafe,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return s
+}
+
+func BenchmarkSignHTTP(b *testing.B) {
+	s := newBenchSigner(b, false)
+	req := newBenchRequest()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.SignHTTP(req, EmptyStringSHA256, benchTime); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSignHTTPParallel(b *testing.B) {
+	s := newBenchSigner(b, true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		req := newBenchRequest()
+		for pb.Next() {
+			if err := s.SignHTTP(req, EmptyStringSHA256, benchTime); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+// BenchmarkSignHTTPBuilders signs the same request with the
+// string-building Build* functions, as a baseline for BenchmarkSignHTTP.
+func BenchmarkSignHTTPBuilders(b *testing.B) {
+	s := newBenchSigner(b, false)
+	req := newBenchRequest()
+	req.Header.Set(AmzDateKey, benchTime.Format(TimeFormat))
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req.Header.Set(AuthorizationHeader, referenceAuthorization(s, req, EmptyStringSHA256, benchTime))
+	}
+}
+
+func BenchmarkPresignHTTP(b *testing.B) {
+	s := newBenchSigner(b, false)
+	req := newBenchRequest()
+	req.URL.RawQuery = "X-Amz-Expires=900"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := s.PresignHTTP(req, UnsignedPayload, benchTime); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPresignHTTPParallel(b *testing.B) {
+	s := newBenchSigner(b, true)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		req := newBenchRequest()
+		req.URL.RawQuery = "X-Amz-Expires=900"
+		for pb.Next() {
+			if _, _, err := s.PresignHTTP(req, UnsignedPayload, benchTime); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkBuildCanonicalHeaders(b *testing.B) {
+	for _, n := range []int{4, 32, 128} {
+		header := make(http.Header, n)
+		for i := 0; i < n; i++ {
+			header.Set(fmt.Sprintf("X-Amz-Meta-Key-%03d", i), fmt.Sprintf("value  %d  with   spaces", i))
+		}
+
+		b.Run(fmt.Sprintf("headers=%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				BuildCanonicalHeaders("bucket.s3.us-east-1.amazonaws.com", IgnoredHeaders, header, 1024)
+			}
+		})
+	}
+}
+
+func BenchmarkStripExcessSpaces(b *testing.B) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"clean", "text/plain; charset=utf-8"},
+		{"trim", "   text/plain; charset=utf-8   "},
+		{"collapse", "text/plain;    charset=utf-8    boundary=x"},
+	}
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				StripExcessSpaces(tt.value)
+			}
+		})
+	}
+}
+
+// benchDeriveKey benchmarks DeriveKey of a deriver created as NewSigner
+// creates it. With miss set, each iteration signs on a new day so the
+// cached key cannot be used.
+func benchDeriveKey(b *testing.B, threadSafe, miss bool) {
+	k := newKeyDerivator(Config{ThreadSafety: threadSafe})
+	st := NewSigningTime(benchTime)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if miss {
+			st = NewSigningTime(benchTime.AddDate(0, 0, i+1))
+		}
+		k.DeriveKey("AKIDEXAMPLE", "SECRET", "s3", "us-east-1", st)
+	}
+}
+
+func BenchmarkDeriveKey(b *testing.B) {
+	for _, threadSafe := range []bool{false, true} {
+		for _, miss := range []bool{false, true} {
+			name := fmt.Sprintf("threadsafe=%t/hit", threadSafe)
+			if miss {
+				name = fmt.Sprintf("threadsafe=%t/miss", threadSafe)
+			}
+			b.Run(name, func(b *testing.B) {
+				benchDeriveKey(b, threadSafe, miss)
+			})
+		}
+	}
+}
+
+func BenchmarkDeriveKeyParallel(b *testing.B) {
+	k := newKeyDerivator(Config{ThreadSafety: true})
+	st := NewSigningTime(benchTime)
+	k.DeriveKey("AKIDEXAMPLE", "SECRET", "s3", "us-east-1", st)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			k.DeriveKey("AKIDEXAMPLE", "SECRET", "s3", "us-east-1", st)
+		}
+	})
+}
@@ -15,12 +15,7 @@ import (
 // Format: date/region/service/aws4_request
 // Reference: AWS SDK v4 signer internal/v4/scope.go
 func BuildCredentialScope(t SigningTime, region, service string) string {
-	return strings.Join([]string{
-		t.ShortTimeFormat(),
-		region,
-		service,
-		"aws4_request",
-	}, "/")
+	return AWSProfile.CredentialScope(t, region, service)
 }
 
 // BuildCanonicalHeaders builds the canonical headers string.
@@ -134,29 +129,7 @@ func BuildSignature(key []byte, stringToSign string) string {
 // Format: ALGORITHM Credential=..., SignedHeaders=..., Signature=...
 // Reference: AWS SDK v4 signer v4.go buildAuthorizationHeader
 func BuildAuthorizationHeader(credentialStr, signedHeadersStr, signature string) string {
-	const credential = "Credential="
-	const signedHeaders = "SignedHeaders="
-	const signatureKey = "Signature="
-	const commaSpace = ", "
-
-	var parts strings.Builder
-	parts.Grow(
-		len(SigningAlgorithm) + 1 +
-			len(credential) + len(credentialStr) + 2 +
-			len(signedHeaders) + len(signedHeadersStr) + 2 +
-			len(signatureKey) + len(signature),
-	)
-	parts.WriteString(SigningAlgorithm)
-	parts.WriteRune(' ')
-	parts.WriteString(credential)
-	parts.WriteString(credentialStr)
-	parts.WriteString(commaSpace)
-	parts.WriteString(signedHeaders)
-	parts.WriteString(signedHeadersStr)
-	parts.WriteString(commaSpace)
-	parts.WriteString(signatureKey)
-	parts.WriteString(signature)
-	return parts.String()
+	return AWSProfile.AuthorizationHeader(credentialStr, signedHeadersStr, signature)
 }
 
 // BuildQuery hoists allowed headers to query parameters.
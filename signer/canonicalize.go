@@ -0,0 +1,201 @@
+package signer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxPooledBufferSize bounds the scratch buffer kept by the pool, so that
+// one request with very large headers does not pin its memory.
+const maxPooledBufferSize = 64 << 10
+
+// signBuffer holds the scratch state of one SignHTTP call. The canonical
+// request, string to sign and Authorization header are written into buf
+// and hashed in place, so signing a typical request allocates little more
+// than the header values it leaves on the request.
+type signBuffer struct {
+	keys   []string
+	buf    []byte
+	hasher hash.Hash
+	pad    [sha256.BlockSize]byte
+	sum    [sha256.Size]byte
+	hex    [sha256.Size * 2]byte
+}
+
+var signBufferPool = sync.Pool{
+	New: func() any {
+		return &signBuffer{hasher: sha256.New()}
+	},
+}
+
+// getSignBuffer returns an empty signBuffer from the pool.
+func getSignBuffer() *signBuffer {
+	return signBufferPool.Get().(*signBuffer)
+}
+
+// release clears b and returns it to the pool.
+func (b *signBuffer) release() {
+	clear(b.keys)
+	b.keys = b.keys[:0]
+	if cap(b.buf) > maxPooledBufferSize {
+		b.buf = nil
+	}
+	b.buf = b.buf[:0]
+	clear(b.pad[:])
+	clear(b.sum[:])
+	signBufferPool.Put(b)
+}
+
+// hashHex hashes data and returns its hex encoding, valid until the next
+// use of b.
+func (b *signBuffer) hashHex(data []byte) []byte {
+	b.hasher.Reset()
+	b.hasher.Write(data)
+	hex.Encode(b.hex[:], b.hasher.Sum(b.sum[:0]))
+	return b.hex[:]
+}
+
+// hmacHex computes HMAC-SHA256 of data with key, as HMACSHA256 does, and
+// returns the raw and hex encoded MAC, valid until the next use of b.
+// Derived signing keys are shorter than the SHA-256 block size, so they
+// are padded rather than hashed.
+func (b *signBuffer) hmacHex(key, data []byte) ([]byte, []byte) {
+	if len(key) > sha256.BlockSize {
+		sum := HMACSHA256(key, data)
+		copy(b.sum[:], sum)
+		hex.Encode(b.hex[:], b.sum[:])
+		return b.sum[:], b.hex[:]
+	}
+
+	// inner = H((key ^ ipad) || data)
+	b.fillPad(key, 0x36)
+	b.hasher.Reset()
+	b.hasher.Write(b.pad[:])
+	b.hasher.Write(data)
+	inner := b.hasher.Sum(b.sum[:0])
+
+	// mac = H((key ^ opad) || inner)
+	b.fillPad(key, 0x5c)
+	b.hasher.Reset()
+	b.hasher.Write(b.pad[:])
+	b.hasher.Write(inner)
+	mac := b.hasher.Sum(b.sum[:0])
+
+	hex.Encode(b.hex[:], mac)
+	return mac, b.hex[:]
+}
+
+// fillPad sets b.pad to key, zero padded to the block size, XORed with x.
+func (b *signBuffer) fillPad(key []byte, x byte) {
+	clear(b.pad[:])
+	copy(b.pad[:], key)
+	for i := range b.pad {
+		b.pad[i] ^= x
+	}
+}
+
+// selectHeaders collects the header keys accepted by rule into b.keys,
+// with the host and, for a positive length, content-length pseudo keys,
+// sorted as BuildCanonicalHeaders sorts them.
+func (b *signBuffer) selectHeaders(rule Rule, header http.Header, length int64) {
+	const hostHeader, contentLengthHeader = "host", "content-length"
+
+	b.keys = append(b.keys, hostHeader)
+	if length > 0 {
+		b.keys = append(b.keys, contentLengthHeader)
+	}
+	for k := range header {
+		if !rule.IsValid(k) {
+			continue
+		}
+		if strings.EqualFold(k, contentLengthHeader) || strings.EqualFold(k, hostHeader) {
+			continue
+		}
+		b.keys = append(b.keys, k)
+	}
+	slices.SortFunc(b.keys, compareLower)
+}
+
+// appendCanonicalHeaders appends the canonical headers of the keys
+// collected by selectHeaders to b.buf, merging keys that differ only in
+// case as BuildCanonicalHeaders does.
+func (b *signBuffer) appendCanonicalHeaders(host string, header http.Header, length int64) {
+	keys := b.keys
+	for i := 0; i < len(keys); i++ {
+		key := keys[i]
+		b.buf = appendLower(b.buf, key)
+		b.buf = append(b.buf, ':')
+		switch key {
+		case "host":
+			b.buf = append(b.buf, StripExcessSpaces(host)...)
+		case "content-length":
+			b.buf = strconv.AppendInt(b.buf, length, 10)
+		default:
+			first := true
+			for ; ; i++ {
+				for _, val := range header[keys[i]] {
+					if !first {
+						b.buf = append(b.buf, ',')
+					}
+					first = false
+					b.buf = append(b.buf, strings.TrimSpace(StripExcessSpaces(val))...)
+				}
+				if i+1 == len(keys) || compareLower(keys[i], keys[i+1]) != 0 {
+					break
+				}
+			}
+		}
+		b.buf = append(b.buf, '\n')
+	}
+}
+
+// appendSignedHeaders appends the signed headers list of the keys
+// collected by selectHeaders to b.buf.
+func (b *signBuffer) appendSignedHeaders() {
+	for i, key := range b.keys {
+		if i > 0 {
+			if compareLower(b.keys[i-1], key) == 0 {
+				continue
+			}
+			b.buf = append(b.buf, ';')
+		}
+		b.buf = appendLower(b.buf, key)
+	}
+}
+
+// compareLower compares a and b as strings.ToLower would order them,
+// for ASCII header names, without allocating.
+func compareLower(a, b string) int {
+	n := min(len(a), len(b))
+	for i := 0; i < n; i++ {
+		ca, cb := lowerASCII(a[i]), lowerASCII(b[i])
+		if ca != cb {
+			if ca < cb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// appendLower appends s to dst with ASCII letters lower cased.
+func appendLower(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		dst = append(dst, lowerASCII(s[i]))
+	}
+	return dst
+}
+
+func lowerASCII(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
@@ -0,0 +1,172 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// referenceAuthorization computes the Authorization header of a request
+// signed by s with the string-building Build* functions.
+func referenceAuthorization(s *Signer, req *http.Request, payloadHash string, signingTime time.Time) string {
+	st := NewSigningTime(signingTime)
+	profile := s.config.Profile
+
+	var length int64
+	if !s.omitLength {
+		length = req.ContentLength
+	}
+	_, signedHeaders, canonicalHeaders := BuildCanonicalHeaders(GetHost(req), s.signRule, req.Header, length)
+	canonicalString := BuildCanonicalString(
+		req.Method,
+		GetURIPath(req.URL),
+		req.URL.RawQuery,
+		signedHeaders,
+		canonicalHeaders,
+		payloadHash,
+	)
+	scope := profile.CredentialScope(st, s.config.Region, s.config.Service)
+	strToSign := BuildStringToSign(profile.Algorithm, st.TimeFormat(), scope, canonicalString)
+	key := s.keyDerivator.DeriveKey(s.config.AccessKeyID, s.config.SecretAccessKey, s.config.Service, s.config.Region, st)
+	return profile.AuthorizationHeader(
+		s.config.AccessKeyID+"/"+scope,
+		signedHeaders,
+		BuildSignature(key, strToSign),
+	)
+}
+
+func TestSignHTTPMatchesBuilders(t *testing.T) {
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		config Config
+		setup  func(req *http.Request)
+
+		// invalid is the field of the *RequestError SignHTTP returns for
+		// a request whose canonicalization is checked without validation.
+		invalid string
+	}{
+		{
+			name:   "no headers",
+			config: testConfig,
+			setup:  func(req *http.Request) {},
+		},
+		{
+			name:   "query and content length",
+			config: testConfig,
+			setup: func(req *http.Request) {
+				req.URL.RawQuery = "prefix=a+b&list-type=2&b=2&b=1&empty"
+				req.ContentLength = 42
+			},
+		},
+		{
+			name:   "excess spaces and duplicate names",
+			config: testConfig,
+			setup: func(req *http.Request) {
+				req.Header.Set("Content-Type", "  text/plain;   charset=utf-8 ")
+				req.Header["x-amz-meta-tag"] = []string{}
+				req.Header.Add("X-Amz-Meta-Tag", "a  b")
+				req.Header.Add("X-Amz-Meta-Tag", "c")
+				req.Header.Set("X-Amz-Meta-Empty", "")
+				req.Header["Host"] = []string{"ignored.example.com"}
+				req.Header.Set("Content-Length", "99")
+			},
+			invalid: "Host",
+		},
+		{
+			name:   "host header without default port",
+			config: testConfig,
+			setup: func(req *http.Request) {
+				req.Header.Set("Host", "bucket.example.com")
+			},
+		},
+		{
+			name:   "ignored headers",
+			config: testConfig,
+			setup: func(req *http.Request) {
+				req.Header.Set("User-Agent", "go-sigv4")
+				req.Header.Set("X-Amzn-Trace-Id", "Root=1-abc")
+				req.Header.Set("Expect", "100-continue")
+			},
+		},
+		{
+			name: "minimal rules",
+			config: Config{
+				Region:          "us-east-1",
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "SECRET",
+				Service:         "s3",
+				HeaderRules:     HeaderRules{Minimal: true},
+			},
+			setup: func(req *http.Request) {
+				req.ContentLength = 10
+				req.Header.Set("Content-Type", "text/plain")
+				req.Header.Set("X-Forwarded-For", "10.0.0.1")
+			},
+		},
+		{
+			name: "gcs profile with session token",
+			config: Config{
+				Region:          "auto",
+				AccessKeyID:     "GOOG1EXAMPLE",
+				SecretAccessKey: "SECRET",
+				Service:         "storage",
+				SessionToken:    "TOKEN",
+				Profile:         GCSProfile,
+			},
+			setup: func(req *http.Request) {
+				req.Header.Set("X-Goog-Meta-Owner", "forestrie")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSigner(tt.config)
+			if err != nil {
+				t.Fatalf("NewSigner failed: %v", err)
+			}
+
+			req, _ := http.NewRequest(http.MethodPut, "https://bucket.example.com:443/a/b%20c.txt", nil)
+			tt.setup(req)
+
+			if tt.invalid != "" {
+				var reqErr *RequestError
+				if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); !errors.As(err, &reqErr) || reqErr.Field != tt.invalid {
+					t.Fatalf("expected *RequestError for %s, got %v", tt.invalid, err)
+				}
+				if err := s.signHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+					t.Fatalf("signHTTP failed: %v", err)
+				}
+			} else if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+				t.Fatalf("SignHTTP failed: %v", err)
+			}
+
+			got := req.Header.Get(AuthorizationHeader)
+			want := referenceAuthorization(s, req, EmptyStringSHA256, signingTime)
+			if got != want {
+				t.Errorf("Authorization mismatch\n got: %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func TestCompareLower(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"Host", "host", 0},
+		{"X-Amz-Date", "x-amz-content-sha256", 1},
+		{"Content-Type", "content-length", 1},
+		{"x-amz-meta", "X-Amz-Meta-Tag", -1},
+	}
+	for _, tt := range tests {
+		got := compareLower(tt.a, tt.b)
+		if (got < 0) != (tt.want < 0) || (got > 0) != (tt.want > 0) {
+			t.Errorf("compareLower(%q, %q) = %d, want sign of %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package signer
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
 
 // Config holds the configuration for SigV4 signing.
-// All fields are required except Service, which defaults to "s3".
+// All fields are required except SessionToken, Service, which defaults
+// to "s3", and SecretAccessKey when KeyDeriver is set.
 type Config struct {
 	// Region is the AWS region (e.g., "auto" for Cloudflare R2).
 	Region string
@@ -14,33 +19,127 @@ type Config struct {
 	// SecretAccessKey is the AWS secret access key.
 	SecretAccessKey string
 
+	// KeyDeriver, if set, derives the signing keys in place of the local
+	// SigV4 key derivation, e.g. from a secret held in an HSM or KMS.
+	// Derived keys are still cached per KeyCacheSize.
+	KeyDeriver KeyDeriver
+
+	// SessionToken is the session token of temporary credentials, if any.
+	// It is sent as X-Amz-Security-Token and covered by the signature.
+	SessionToken string
+
+	// Expires, if set, is the time temporary credentials expire. Signing
+	// at or after it fails with an *ExpiredCredentialsError.
+	Expires time.Time
+
 	// Service is the AWS service name (defaults to "s3").
 	// For Cloudflare R2, this should be "s3".
 	Service string
 
+	// Profile selects the signing scheme, e.g. GCSProfile for Google
+	// Cloud Storage HMAC keys (defaults to AWSProfile).
+	Profile Profile
+
+	// HeaderRules adjusts which headers are signed and hoisted.
+	HeaderRules HeaderRules
+
 	// ThreadSafety enables thread-safe operation of the Signer.
 	// When true, the Signer can be used concurrently from multiple goroutines.
 	// When false, the Signer must be used from a single goroutine at a time.
 	ThreadSafety bool
 
+	// KeyCacheSize bounds the number of derived signing keys cached per
+	// access key, region, service and day (defaults to
+	// DefaultKeyCacheSize). Multi-tenant signers should allow at least one
+	// key per tenant and region.
+	KeyCacheSize int
+
 	// DisableHeaderHoisting prevents headers from being moved to query
 	// string during presigning.
 	DisableHeaderHoisting bool
+
+	// Logger, if set, receives signing and verification events at debug
+	// level. Secrets are never logged and signatures only in part.
+	Logger *slog.Logger
+
+	// Observer, if set, is called with the duration and outcome of each
+	// signing, key derivation and verification, for metrics or tracing.
+	Observer Observer
 }
 
-// Validate checks that all required fields are set.
+// Validate checks that all required fields are set, returning a
+// *ConfigError for the first that is not.
 func (c *Config) Validate() error {
 	if c.Region == "" {
-		return fmt.Errorf("region is required")
+		return &ConfigError{Field: "Region", Reason: "is required"}
 	}
 	if c.AccessKeyID == "" {
-		return fmt.Errorf("access key ID is required")
+		return &ConfigError{Field: "AccessKeyID", Reason: "is required"}
 	}
-	if c.SecretAccessKey == "" {
-		return fmt.Errorf("secret access key is required")
+	if c.SecretAccessKey == "" && c.KeyDeriver == nil {
+		return &ConfigError{Field: "SecretAccessKey", Reason: "is required"}
 	}
 	if c.Service == "" {
 		c.Service = "s3"
 	}
+	c.Profile = c.Profile.orDefault()
+	return nil
+}
+
+// checkExpiry returns an *ExpiredCredentialsError if the credentials have
+// expired at signingTime.
+func (c *Config) checkExpiry(signingTime time.Time) error {
+	if !c.Expires.IsZero() && !signingTime.Before(c.Expires) {
+		return &ExpiredCredentialsError{
+			AccessKeyID: c.AccessKeyID,
+			Expires:     c.Expires,
+			SigningTime: signingTime,
+		}
+	}
 	return nil
 }
+
+// redacted replaces secrets when a Config is formatted or logged.
+const redacted = "[REDACTED]"
+
+// redact returns redacted for a non-empty secret.
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
+
+// String formats the Config with SecretAccessKey and SessionToken
+// redacted, and is also used for %v and %+v.
+func (c Config) String() string {
+	return fmt.Sprintf(
+		"Config{Region: %s, AccessKeyID: %s, SecretAccessKey: %s, SessionToken: %s, Service: %s, Algorithm: %s, ThreadSafety: %t}",
+		c.Region,
+		c.AccessKeyID,
+		redact(c.SecretAccessKey),
+		redact(c.SessionToken),
+		c.Service,
+		c.Profile.orDefault().Algorithm,
+		c.ThreadSafety,
+	)
+}
+
+// GoString formats the Config for %#v with secrets redacted.
+func (c Config) GoString() string {
+	return "signer." + c.String()
+}
+
+// LogValue implements slog.LogValuer, logging the Config with secrets
+// redacted.
+func (c Config) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("region", c.Region),
+		slog.String("access_key_id", c.AccessKeyID),
+		slog.String("secret_access_key", redact(c.SecretAccessKey)),
+		slog.String("session_token", redact(c.SessionToken)),
+		slog.String("service", c.Service),
+		slog.String("algorithm", c.Profile.orDefault().Algorithm),
+		slog.Bool("thread_safety", c.ThreadSafety),
+	)
+}
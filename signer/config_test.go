@@ -0,0 +1,83 @@
+package signer
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestConfigRedaction(t *testing.T) {
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG",
+		SessionToken:    "FwoGZXIvYXdzEBYaDH",
+		Service:         "s3",
+	}
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("signing", "config", config)
+
+	outputs := map[string]string{
+		"String": config.String(),
+		"%v":     fmt.Sprintf("%v", config),
+		"%+v":    fmt.Sprintf("%+v", config),
+		"%#v":    fmt.Sprintf("%#v", config),
+		"&%+v":   fmt.Sprintf("%+v", &config),
+		"error":  fmt.Errorf("bad config %v", config).Error(),
+		"slog":   logged.String(),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, config.SecretAccessKey) || strings.Contains(out, config.SessionToken) {
+			t.Errorf("%s leaks a secret: %s", name, out)
+		}
+		if !strings.Contains(out, "AKID") || !strings.Contains(out, redacted) {
+			t.Errorf("%s = %s, want access key ID and %s", name, out, redacted)
+		}
+	}
+}
+
+func TestSignerRedaction(t *testing.T) {
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG",
+		SessionToken:    "FwoGZXIvYXdzEBYaDH",
+		Service:         "s3",
+	}
+	s, _ := NewSigner(config)
+	v, _ := NewVerifier(config)
+	p, _ := NewPostPolicyVerifier(config)
+	v2, _ := NewV2Signer(config)
+	g, _ := NewMSKTokenGenerator(config)
+	mv, _ := NewMSKVerifier(config)
+	values := map[string]interface{}{
+		"Signer":             s,
+		"Verifier":           v,
+		"PostPolicyVerifier": p,
+		"V2Signer":           v2,
+		"StreamSigner":       s.NewStreamSigner(nil),
+		"MSKTokenGenerator":  g,
+		"MSKVerifier":        mv,
+	}
+	for name, value := range values {
+		for _, format := range []string{"%v", "%+v", "%#v"} {
+			out := fmt.Sprintf(format, value)
+			if strings.Contains(out, config.SecretAccessKey) || strings.Contains(out, config.SessionToken) {
+				t.Errorf("%s %s leaks a secret: %s", name, format, out)
+			}
+			if !strings.Contains(out, "AKID") || !strings.Contains(out, redacted) {
+				t.Errorf("%s %s = %s, want access key ID and %s", name, format, out, redacted)
+			}
+		}
+	}
+
+	key := ScopedKey{AccessKeyID: "AKID", Date: "20240115", Region: "us-east-1", Service: "s3", Key: []byte("derived-key-bytes")}
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		if out := fmt.Sprintf(format, key); strings.Contains(out, "derived-key-bytes") || strings.Contains(out, "100 101") {
+			t.Errorf("ScopedKey %s leaks the key: %s", format, out)
+		}
+	}
+}
@@ -8,6 +8,10 @@ const (
 	// Used for x-amz-content-sha256 header on requests with no body.
 	EmptyStringSHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
 
+	// UnsignedPayload is used in place of the payload hash when the body
+	// is not signed, as is usual for presigned URLs.
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+
 	// SigningAlgorithm is the SigV4 signing algorithm identifier.
 	SigningAlgorithm = "AWS4-HMAC-SHA256"
 
@@ -30,6 +34,14 @@ const (
 	// AmzSignatureKey is the query parameter key for the signature.
 	AmzSignatureKey = "X-Amz-Signature"
 
+	// AmzSecurityTokenKey is the header/query key for the session token
+	// of temporary credentials.
+	AmzSecurityTokenKey = "X-Amz-Security-Token"
+
+	// AmzExpiresKey is the query parameter key for presigned URL expiry,
+	// given in seconds.
+	AmzExpiresKey = "X-Amz-Expires"
+
 	// ContentSHAKey is the header key for request body SHA256 hash.
 	ContentSHAKey = "X-Amz-Content-Sha256"
 
@@ -0,0 +1,57 @@
+package signer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// credential is the parsed form of an X-Amz-Credential value.
+// Format: accessKeyID/date/region/service/terminator
+// Reference: AWS SigV4 spec, "Credential scope"
+type credential struct {
+	AccessKeyID string
+	Date        string
+	Region      string
+	Service     string
+	Terminator  string
+}
+
+// parseCredential splits a credential string into its components.
+func parseCredential(value string) (credential, error) {
+	parts := strings.Split(value, "/")
+	if len(parts) != 5 {
+		return credential{}, fmt.Errorf("%w %q", ErrMalformedCredential, value)
+	}
+	for _, p := range parts {
+		if p == "" {
+			return credential{}, fmt.Errorf("%w %q", ErrMalformedCredential, value)
+		}
+	}
+	return credential{
+		AccessKeyID: parts[0],
+		Date:        parts[1],
+		Region:      parts[2],
+		Service:     parts[3],
+		Terminator:  parts[4],
+	}, nil
+}
+
+// check verifies that the credential was issued for the given config and
+// that its scope date matches the signing time, returning a
+// *CredentialError for the first component that does not.
+func (c credential) check(config Config, t SigningTime) error {
+	for _, f := range []struct {
+		field, value, expected string
+	}{
+		{"AccessKeyID", c.AccessKeyID, config.AccessKeyID},
+		{"Region", c.Region, config.Region},
+		{"Service", c.Service, config.Service},
+		{"Terminator", c.Terminator, config.Profile.orDefault().Terminator},
+		{"Date", c.Date, t.ShortTimeFormat()},
+	} {
+		if f.value != f.expected {
+			return &CredentialError{Field: f.field, Value: f.value, Expected: f.expected}
+		}
+	}
+	return nil
+}
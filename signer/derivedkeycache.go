@@ -0,0 +1,111 @@
+package signer
+
+import "container/list"
+
+// DefaultKeyCacheSize is the number of derived keys a Signer caches when
+// Config.KeyCacheSize is not set.
+const DefaultKeyCacheSize = 128
+
+// KeyCacheStats counts derived key cache lookups.
+type KeyCacheStats struct {
+	// Hits counts keys served from the cache.
+	Hits uint64
+
+	// Misses counts keys that had to be derived.
+	Misses uint64
+
+	// Evictions counts keys dropped to bound the cache size or because a
+	// later day's key was cached.
+	Evictions uint64
+}
+
+// derivedKey is a cached derived key.
+type derivedKey struct {
+	id  lookupKey
+	key []byte
+}
+
+// keyLRU caches derived keys per access key, region, service and day,
+// evicting the least recently used key when full. Keys of a day are
+// dropped once a key for a later day is cached, as SigV4 keys are only
+// valid for the day of their credential scope. Dropped keys are zeroed,
+// so the cache owns the key slices it holds. keyLRU is not safe for
+// concurrent use.
+type keyLRU struct {
+	size   int
+	day    int
+	order  *list.List
+	values map[lookupKey]*list.Element
+	stats  KeyCacheStats
+}
+
+// newKeyLRU creates a cache of at most size keys, or DefaultKeyCacheSize
+// if size is not positive.
+func newKeyLRU(size int) keyLRU {
+	if size <= 0 {
+		size = DefaultKeyCacheSize
+	}
+	return keyLRU{
+		size:   size,
+		order:  list.New(),
+		values: make(map[lookupKey]*list.Element),
+	}
+}
+
+// get returns the cached key for id and marks it most recently used.
+func (c *keyLRU) get(id lookupKey) ([]byte, bool) {
+	e, ok := c.values[id]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(e)
+	return e.Value.(*derivedKey).key, true
+}
+
+// set caches key for id, evicting earlier days' keys and then the least
+// recently used keys beyond the size bound.
+func (c *keyLRU) set(id lookupKey, key []byte) {
+	if id.day > c.day {
+		c.day = id.day
+		for e := c.order.Front(); e != nil; {
+			next := e.Next()
+			if e.Value.(*derivedKey).id.day < id.day {
+				c.remove(e)
+			}
+			e = next
+		}
+	}
+
+	if e, ok := c.values[id]; ok {
+		entry := e.Value.(*derivedKey)
+		clear(entry.key)
+		entry.key = key
+		c.order.MoveToFront(e)
+		return
+	}
+	c.values[id] = c.order.PushFront(&derivedKey{id: id, key: key})
+
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove evicts the key of e, zeroing its bytes.
+func (c *keyLRU) remove(e *list.Element) {
+	entry := e.Value.(*derivedKey)
+	c.order.Remove(e)
+	delete(c.values, entry.id)
+	clear(entry.key)
+	c.stats.Evictions++
+}
+
+// wipe zeroes and drops all cached keys. Counters are kept.
+func (c *keyLRU) wipe() {
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		clear(e.Value.(*derivedKey).key)
+	}
+	c.order.Init()
+	clear(c.values)
+}
@@ -0,0 +1,169 @@
+package signer
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestKeyLRUEviction(t *testing.T) {
+	c := newKeyLRU(2)
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	a := newLookupKey("A", "s3", "us-east-1", day)
+	b := newLookupKey("B", "s3", "us-east-1", day)
+	d := newLookupKey("D", "s3", "us-east-1", day)
+
+	c.set(a, []byte("a"))
+	c.set(b, []byte("b"))
+	if _, ok := c.get(a); !ok {
+		t.Fatal("expected hit for A")
+	}
+	// B is now least recently used.
+	c.set(d, []byte("d"))
+
+	if _, ok := c.get(b); ok {
+		t.Error("expected B to be evicted")
+	}
+	if key, ok := c.get(a); !ok || string(key) != "a" {
+		t.Errorf("expected hit for A, got %q %v", key, ok)
+	}
+	if key, ok := c.get(d); !ok || string(key) != "d" {
+		t.Errorf("expected hit for D, got %q %v", key, ok)
+	}
+
+	want := KeyCacheStats{Hits: 3, Misses: 1, Evictions: 1}
+	if c.stats != want {
+		t.Errorf("stats = %+v, want %+v", c.stats, want)
+	}
+}
+
+func TestKeyLRUExpiresPreviousDays(t *testing.T) {
+	c := newKeyLRU(0)
+	day1 := time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+
+	c.set(newLookupKey("A", "s3", "us-east-1", day1), []byte("a1"))
+	c.set(newLookupKey("B", "s3", "us-east-1", day1), []byte("b1"))
+	c.set(newLookupKey("A", "s3", "us-east-1", day2), []byte("a2"))
+
+	if c.order.Len() != 1 {
+		t.Errorf("expected 1 cached key, got %d", c.order.Len())
+	}
+	if c.stats.Evictions != 2 {
+		t.Errorf("expected 2 evictions, got %d", c.stats.Evictions)
+	}
+	if _, ok := c.get(newLookupKey("B", "s3", "us-east-1", day1)); ok {
+		t.Error("expected previous day key to be expired")
+	}
+}
+
+func TestSignerKeyCacheMultiTenant(t *testing.T) {
+	for _, threadSafe := range []bool{false, true} {
+		deriver := newKeyDerivator(Config{ThreadSafety: threadSafe}).(*SigningKeyDeriver)
+		st := NewSigningTime(time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+
+		// Alternating tenants share the cache without rederiving.
+		for i := 0; i < 10; i++ {
+			deriver.DeriveKey("TENANT1", "SECRET1", "s3", "us-east-1", st)
+			deriver.DeriveKey("TENANT2", "SECRET2", "s3", "us-east-1", st)
+		}
+
+		want := KeyCacheStats{Hits: 18, Misses: 2}
+		if got := deriver.Stats(); got != want {
+			t.Errorf("threadSafe=%v: stats = %+v, want %+v", threadSafe, got, want)
+		}
+	}
+}
+
+func TestSignerKeyCacheStats(t *testing.T) {
+	config := testConfig
+	config.KeyCacheSize = 1
+	s, err := NewSigner(config)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+
+	day := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	for _, signingTime := range []time.Time{day, day.Add(time.Hour), day.AddDate(0, 0, 1)} {
+		req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("SignHTTP failed: %v", err)
+		}
+	}
+
+	want := KeyCacheStats{Hits: 1, Misses: 2, Evictions: 1}
+	if got := s.KeyCacheStats(); got != want {
+		t.Errorf("KeyCacheStats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestKeyLRUZeroesEvictedKeys(t *testing.T) {
+	c := newKeyLRU(1)
+	day := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	a := []byte{1, 2, 3}
+	b := []byte{4, 5, 6}
+
+	c.set(newLookupKey("A", "s3", "us-east-1", day), a)
+	c.set(newLookupKey("B", "s3", "us-east-1", day), b)
+	if !bytes.Equal(a, []byte{0, 0, 0}) {
+		t.Errorf("evicted key not zeroed: %v", a)
+	}
+
+	c.wipe()
+	if !bytes.Equal(b, []byte{0, 0, 0}) {
+		t.Errorf("wiped key not zeroed: %v", b)
+	}
+	if c.order.Len() != 0 || len(c.values) != 0 {
+		t.Error("expected wipe to empty the cache")
+	}
+}
+
+func TestSignerClose(t *testing.T) {
+	for _, threadSafe := range []bool{false, true} {
+		config := testConfig
+		config.ThreadSafety = threadSafe
+		s, err := NewSigner(config)
+		if err != nil {
+			t.Fatalf("NewSigner failed: %v", err)
+		}
+
+		signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+		req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("SignHTTP failed: %v", err)
+		}
+
+		if err := s.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err == nil {
+			t.Errorf("threadSafe=%v: expected SignHTTP to fail after Close", threadSafe)
+		}
+	}
+}
+
+func TestScopedSignerClose(t *testing.T) {
+	full, _ := NewSigner(testConfig)
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	key, err := full.ScopedKey(signingTime)
+	if err != nil {
+		t.Fatalf("ScopedKey failed: %v", err)
+	}
+	scoped, err := NewScopedSigner(Config{}, key)
+	if err != nil {
+		t.Fatalf("NewScopedSigner failed: %v", err)
+	}
+
+	if err := scoped.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if err := scoped.SignHTTP(req, EmptyStringSHA256, signingTime); err == nil {
+		t.Error("expected SignHTTP to fail after Close")
+	}
+	// The caller's copy of the key is not affected.
+	if bytes.Equal(key.Key, make([]byte, len(key.Key))) {
+		t.Error("Close zeroed the caller's ScopedKey")
+	}
+}
@@ -1,44 +1,39 @@
 package signer
 
-import "time"
-
-// derivedKeyCacheNoThr caches derived keys per region/service/date combination.
-// This implementation is not thread-safe and assumes the caller ensures
-// single-threaded access. Each Signer instance must be used from a single
-// goroutine at a time when using this cache.
+// derivedKeyCacheNoThr caches derived keys per access key, region, service
+// and day in a bounded LRU. This implementation is not thread-safe and
+// assumes the caller ensures single-threaded access. Each Signer instance
+// must be used from a single goroutine at a time when using this cache.
 // Reference: AWS SDK v4 signer internal/v4/cache.go derivedKeyCache
 type derivedKeyCacheNoThr struct {
-	values map[string]derivedKey
+	lru keyLRU
 }
 
-// newDerivedKeyCacheNoThr creates a new non-thread-safe cache.
-func newDerivedKeyCacheNoThr() *derivedKeyCacheNoThr {
+// newDerivedKeyCacheNoThr creates a new non-thread-safe cache of at most
+// size keys.
+func newDerivedKeyCacheNoThr(size int) *derivedKeyCacheNoThr {
 	return &derivedKeyCacheNoThr{
-		values: make(map[string]derivedKey),
+		lru: newKeyLRU(size),
 	}
 }
 
-// get retrieves a cached key if it exists and is valid.
-func (c *derivedKeyCacheNoThr) get(key string, accessKeyID string, t time.Time) ([]byte, bool) {
-	entry, ok := c.values[key]
-	if !ok {
-		return nil, false
-	}
-	if entry.accessKeyID != accessKeyID {
-		return nil, false
-	}
-	if !isSameDay(t, entry.date) {
-		return nil, false
-	}
-	return entry.key, true
+// get retrieves a cached key if it exists. The key is zeroed when it is
+// evicted, so it must not be retained by the caller.
+func (c *derivedKeyCacheNoThr) get(key lookupKey) ([]byte, bool) {
+	return c.lru.get(key)
 }
 
-// set stores a derived key in the cache.
-func (c *derivedKeyCacheNoThr) set(key string, accessKeyID string, t time.Time, k []byte) {
-	c.values[key] = derivedKey{
-		accessKeyID: accessKeyID,
-		date:        t,
-		key:         k,
-	}
+// set stores a derived key in the cache, which takes ownership of k.
+func (c *derivedKeyCacheNoThr) set(key lookupKey, k []byte) {
+	c.lru.set(key, k)
+}
+
+// stats returns the cache counters.
+func (c *derivedKeyCacheNoThr) stats() KeyCacheStats {
+	return c.lru.stats
 }
 
+// wipe zeroes and drops all cached keys.
+func (c *derivedKeyCacheNoThr) wipe() {
+	c.lru.wipe()
+}
@@ -1,55 +1,54 @@
 package signer
 
-import (
-	"sync"
-	"time"
-)
+import "sync"
 
-// derivedKeyCacheThr caches derived keys per region/service/date combination.
-// This implementation is thread-safe and can be used concurrently from
-// multiple goroutines.
+// derivedKeyCacheThr caches derived keys per access key, region, service
+// and day in a bounded LRU. This implementation is thread-safe and can be
+// used concurrently from multiple goroutines.
 // Reference: AWS SDK v4 signer internal/v4/cache.go derivedKeyCache
 type derivedKeyCacheThr struct {
-	mu     sync.RWMutex
-	values map[string]derivedKey
+	mu  sync.Mutex
+	lru keyLRU
 }
 
-// newDerivedKeyCacheThr creates a new thread-safe cache.
-func newDerivedKeyCacheThr() *derivedKeyCacheThr {
+// newDerivedKeyCacheThr creates a new thread-safe cache of at most size
+// keys.
+func newDerivedKeyCacheThr(size int) *derivedKeyCacheThr {
 	return &derivedKeyCacheThr{
-		values: make(map[string]derivedKey),
+		lru: newKeyLRU(size),
 	}
 }
 
-// get retrieves a cached key if it exists and is valid.
-// Uses a read lock for thread-safe access.
-func (c *derivedKeyCacheThr) get(key string, accessKeyID string, t time.Time) ([]byte, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-
-	entry, ok := c.values[key]
+// get retrieves a copy of a cached key if it exists. The key is copied
+// as another goroutine may evict and zero the cached key while it is in
+// use. A cache hit updates the LRU order, so an exclusive lock is taken.
+func (c *derivedKeyCacheThr) get(key lookupKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k, ok := c.lru.get(key)
 	if !ok {
 		return nil, false
 	}
-	if entry.accessKeyID != accessKeyID {
-		return nil, false
-	}
-	if !isSameDay(t, entry.date) {
-		return nil, false
-	}
-	return entry.key, true
+	return append([]byte(nil), k...), true
 }
 
-// set stores a derived key in the cache.
-// Uses a write lock for thread-safe access.
-func (c *derivedKeyCacheThr) set(key string, accessKeyID string, t time.Time, k []byte) {
+// set stores a copy of a derived key in the cache.
+func (c *derivedKeyCacheThr) set(key lookupKey, k []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.lru.set(key, append([]byte(nil), k...))
+}
 
-	c.values[key] = derivedKey{
-		accessKeyID: accessKeyID,
-		date:        t,
-		key:         k,
-	}
+// stats returns the cache counters.
+func (c *derivedKeyCacheThr) stats() KeyCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.stats
 }
 
+// wipe zeroes and drops all cached keys.
+func (c *derivedKeyCacheThr) wipe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lru.wipe()
+}
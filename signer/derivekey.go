@@ -13,19 +13,7 @@ import (
 //   - kSigning = HMAC-SHA256(kService, "aws4_request")
 // Reference: AWS SDK v4 signer internal/v4/cache.go deriveKey function
 func DeriveKey(secret, service, region string, t SigningTime) []byte {
-	dateStr := t.ShortTimeFormat()
-
-	// kDate = HMAC-SHA256("AWS4" + secret, date)
-	kDate := HMACSHA256([]byte("AWS4"+secret), []byte(dateStr))
-
-	// kRegion = HMAC-SHA256(kDate, region)
-	kRegion := HMACSHA256(kDate, []byte(region))
-
-	// kService = HMAC-SHA256(kRegion, service)
-	kService := HMACSHA256(kRegion, []byte(service))
-
-	// kSigning = HMAC-SHA256(kService, "aws4_request")
-	return HMACSHA256(kService, []byte("aws4_request"))
+	return AWSProfile.DeriveKey(secret, service, region, t)
 }
 
 // HMACSHA256 computes HMAC-SHA256 of data with the given key.
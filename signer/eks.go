@@ -0,0 +1,62 @@
+package signer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// EKSTokenPrefix prefixes every EKS bearer token.
+	EKSTokenPrefix = "k8s-aws-v1."
+
+	// EKSClusterIDHeader names the cluster a token is issued for. It must
+	// be signed so that a token cannot be replayed against another cluster.
+	EKSClusterIDHeader = "X-K8s-Aws-Id"
+
+	// eksPresignExpiry is the X-Amz-Expires used by aws-iam-authenticator.
+	// EKS itself accepts tokens for up to 15 minutes after signing.
+	eksPresignExpiry = 60 * time.Second
+)
+
+// BuildEKSToken generates a Kubernetes bearer token for the EKS cluster
+// clusterID. The token is a presigned STS GetCallerIdentity URL for
+// config.Region, with the cluster ID header signed, base64url encoded and
+// prefixed with EKSTokenPrefix. config.Service and
+// config.DisableHeaderHoisting are overridden.
+// Reference: aws-iam-authenticator pkg/token Generator
+func BuildEKSToken(clusterID string, config Config, signingTime time.Time) (string, error) {
+	if clusterID == "" {
+		return "", &ConfigError{Field: "ClusterID", Reason: "is required"}
+	}
+
+	config.Service = "sts"
+	// The cluster ID header must stay a signed header rather than be
+	// hoisted into the query, as EKS supplies it when verifying.
+	config.DisableHeaderHoisting = true
+	signer, err := NewSigner(config)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("Action", "GetCallerIdentity")
+	query.Set("Version", "2011-06-15")
+	query.Set(AmzExpiresKey, strconv.Itoa(int(eksPresignExpiry/time.Second)))
+
+	endpoint := "https://sts." + config.Region + ".amazonaws.com/?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", &ConfigError{Field: "Region", Reason: fmt.Sprintf("%q is not a valid host name: %v", config.Region, err)}
+	}
+	req.Header.Set(EKSClusterIDHeader, clusterID)
+
+	signedURL, _, err := signer.PresignHTTP(req, EmptyStringSHA256, signingTime)
+	if err != nil {
+		return "", err
+	}
+	return EKSTokenPrefix + base64.RawURLEncoding.EncodeToString([]byte(signedURL)), nil
+}
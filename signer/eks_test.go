@@ -0,0 +1,94 @@
+package signer
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildEKSToken(t *testing.T) {
+	config := Config{
+		Region:          "eu-west-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+	}
+	signingTime := time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	token, err := BuildEKSToken("my-cluster", config, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(token, EKSTokenPrefix) {
+		t.Fatalf("expected %s prefix, got %s", EKSTokenPrefix, token)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(token, EKSTokenPrefix))
+	if err != nil {
+		t.Fatalf("token is not base64url: %v", err)
+	}
+	u, err := url.Parse(string(raw))
+	if err != nil {
+		t.Fatalf("failed to parse token URL: %v", err)
+	}
+
+	if u.Host != "sts.eu-west-1.amazonaws.com" {
+		t.Errorf("unexpected host %s", u.Host)
+	}
+	q := u.Query()
+	if q.Get("Action") != "GetCallerIdentity" || q.Get(AmzExpiresKey) != "60" {
+		t.Errorf("unexpected query %s", u.RawQuery)
+	}
+	if q.Get(AmzSignedHeadersKey) != "host;x-k8s-aws-id" {
+		t.Errorf("expected cluster ID header to be signed, got %s", q.Get(AmzSignedHeadersKey))
+	}
+	if q.Has(EKSClusterIDHeader) {
+		t.Error("cluster ID header should not be hoisted into the query")
+	}
+
+	// EKS verifies the token by adding the cluster ID header.
+	config.Service = "sts"
+	verifier, _ := NewVerifier(config)
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	req.Header.Set(EKSClusterIDHeader, "my-cluster")
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+		t.Errorf("expected token to verify, got %v", err)
+	}
+
+	req.Header.Set(EKSClusterIDHeader, "other-cluster")
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime); err == nil {
+		t.Error("expected token to be rejected for another cluster")
+	}
+}
+
+func TestPresignHTTPHeaderHoisting(t *testing.T) {
+	for _, disable := range []bool{false, true} {
+		config := testConfig
+		config.DisableHeaderHoisting = disable
+		signer, _ := NewSigner(config)
+
+		req, _ := buildTestRequest("GET", "https://example.com/bucket/key", "")
+		req.Header.Set(EKSClusterIDHeader, "cluster")
+		req.Header.Set("X-Amz-Request-Payer", "requester")
+
+		signedURL, signedHeaders, err := signer.PresignHTTP(req, UnsignedPayload, time.Now())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		u, _ := url.Parse(signedURL)
+		q := u.Query()
+
+		// Only X-Amz- headers are eligible for hoisting.
+		if signedHeaders.Get(EKSClusterIDHeader) != "cluster" {
+			t.Errorf("disable=%t: expected cluster ID header to be signed", disable)
+		}
+		if q.Has("x-amz-request-payer") == disable {
+			t.Errorf("disable=%t: unexpected hoisting in query %s", disable, u.RawQuery)
+		}
+		if (signedHeaders.Get("X-Amz-Request-Payer") != "") != disable {
+			t.Errorf("disable=%t: unexpected signed headers %v", disable, signedHeaders)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package signer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Errors returned, possibly wrapped, by signing and verification. Test
+// for them with errors.Is.
+var (
+	// ErrInvalidRequest matches every *RequestError.
+	ErrInvalidRequest = newSentinel(ErrorCategoryRequest, "invalid request")
+
+	// ErrMissingPayloadHash is returned when no payload hash is given.
+	ErrMissingPayloadHash = newSentinel(ErrorCategoryRequest, "payload hash is required")
+
+	// ErrMissingAuthorization is returned by VerifyHTTP for a request
+	// without an Authorization header.
+	ErrMissingAuthorization = newSentinel(ErrorCategoryRequest, "authorization header is required")
+
+	// ErrMissingSignature is returned when the credential, signed headers
+	// or signature of a signed request are missing.
+	ErrMissingSignature = newSentinel(ErrorCategoryRequest, "credential, signed headers and signature are required")
+
+	// ErrUnsupportedAlgorithm is returned for a signature of another
+	// algorithm than that of the Config's Profile.
+	ErrUnsupportedAlgorithm = newSentinel(ErrorCategoryRequest, "unsupported signing algorithm")
+
+	// ErrMalformedCredential is returned for a credential that is not of
+	// the form accessKeyID/date/region/service/terminator.
+	ErrMalformedCredential = newSentinel(ErrorCategoryRequest, "malformed credential")
+
+	// ErrInvalidSigningTime is returned for a missing or malformed date
+	// parameter, such as X-Amz-Date.
+	ErrInvalidSigningTime = newSentinel(ErrorCategoryRequest, "invalid signing time")
+
+	// ErrInvalidPresignExpiry is returned for a presign expiry that is not
+	// positive or exceeds MaxPresignExpiry.
+	ErrInvalidPresignExpiry = newSentinel(ErrorCategoryRequest, "invalid presign expiry")
+
+	// ErrInvalidURL is returned for a URL that cannot be signed.
+	ErrInvalidURL = newSentinel(ErrorCategoryRequest, "invalid URL")
+
+	// ErrSignedHeadersMismatch is returned when the signed headers of a
+	// request do not match the headers it carries.
+	ErrSignedHeadersMismatch = newSentinel(ErrorCategoryRequest, "signed headers do not match request headers")
+
+	// ErrSecurityTokenMismatch is returned when the session token of a
+	// request does not match Config.SessionToken.
+	ErrSecurityTokenMismatch = newSentinel(ErrorCategoryCredential, "security token does not match")
+
+	// ErrNoSigningKey is returned when the KeyDeriver cannot derive a
+	// signing key.
+	ErrNoSigningKey = newSentinel(ErrorCategoryCredential, "no signing key")
+
+	// ErrClosed is returned when signing with a closed Signer.
+	ErrClosed = newSentinel(ErrorCategoryCredential, "signing keys are closed")
+
+	// ErrPresignExpired is returned for a presigned request used after
+	// its expiry.
+	ErrPresignExpired = newSentinel(ErrorCategoryExpired, "presigned request expired")
+
+	// ErrSignatureMismatch is returned when a signature does not match
+	// the request.
+	ErrSignatureMismatch = newSentinel(ErrorCategorySignature, "signature does not match")
+
+	// ErrInvalidPostUpload is returned for a malformed POST upload.
+	ErrInvalidPostUpload = newSentinel(ErrorCategoryRequest, "invalid POST upload")
+
+	// ErrInvalidPostPolicy is returned for a malformed POST policy.
+	ErrInvalidPostPolicy = newSentinel(ErrorCategoryRequest, "invalid POST policy")
+
+	// ErrPostPolicyExpired is returned for a POST upload after its policy
+	// expired.
+	ErrPostPolicyExpired = newSentinel(ErrorCategoryExpired, "POST policy expired")
+
+	// ErrInvalidMSKToken is returned by MSKVerifier for a malformed MSK
+	// token or authentication payload.
+	ErrInvalidMSKToken = newSentinel(ErrorCategoryRequest, "invalid MSK token")
+)
+
+// categorized is implemented by the errors of this package to report
+// their ErrorCategory.
+type categorized interface {
+	error
+	category() ErrorCategory
+}
+
+// sentinelError is the type of the Err* values.
+type sentinelError struct {
+	msg string
+	cat ErrorCategory
+}
+
+func newSentinel(category ErrorCategory, msg string) error {
+	return &sentinelError{msg: msg, cat: category}
+}
+
+func (e *sentinelError) Error() string           { return e.msg }
+func (e *sentinelError) category() ErrorCategory { return e.cat }
+
+// ConfigError reports an invalid Config or ScopedKey field, or an invalid
+// argument, such as an endpoint or cluster ID, to a token or endpoint
+// constructor.
+type ConfigError struct {
+	// Field is the name of the field, e.g. "Region".
+	Field string
+
+	// Reason describes the problem, e.g. "is required".
+	Reason string
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config: %s %s", e.Field, e.Reason)
+}
+
+func (e *ConfigError) category() ErrorCategory { return ErrorCategoryConfig }
+
+// RequestError reports a request that cannot be signed as given, because
+// the service would reject it whatever its signature. It matches
+// ErrInvalidRequest.
+type RequestError struct {
+	// Field is the request field or header at fault, e.g. "URL",
+	// "ContentLength" or "X-Amz-Content-Sha256".
+	Field string
+
+	// Reason describes the problem.
+	Reason string
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("invalid request: %s %s", e.Field, e.Reason)
+}
+
+func (e *RequestError) Is(target error) bool { return target == ErrInvalidRequest }
+
+func (e *RequestError) category() ErrorCategory { return ErrorCategoryRequest }
+
+// CredentialError reports a credential that was not issued for the
+// verifier's or scoped signer's access key, region or service, or whose
+// date is not that of the signing time.
+type CredentialError struct {
+	// Field is the credential component, one of "AccessKeyID", "Date",
+	// "Region", "Service" or "Terminator".
+	Field string
+
+	// Value is the value in the credential.
+	Value string
+
+	// Expected is the value required.
+	Expected string
+}
+
+func (e *CredentialError) Error() string {
+	if e.Field == "AccessKeyID" {
+		return fmt.Sprintf("unknown access key ID %q", e.Value)
+	}
+	return fmt.Sprintf("credential %s %q does not match %q", strings.ToLower(e.Field), e.Value, e.Expected)
+}
+
+func (e *CredentialError) category() ErrorCategory { return ErrorCategoryCredential }
+
+// ClockSkewError reports a request whose signing time is more than
+// MaxClockSkew from the time it is verified.
+type ClockSkewError struct {
+	SigningTime time.Time
+	Now         time.Time
+}
+
+func (e *ClockSkewError) Error() string {
+	return fmt.Sprintf("signing time %s is too far from %s", e.SigningTime.UTC().Format(TimeFormat), e.Now.UTC().Format(TimeFormat))
+}
+
+func (e *ClockSkewError) category() ErrorCategory { return ErrorCategoryExpired }
+
+// ExpiredCredentialsError reports signing at or after Config.Expires.
+type ExpiredCredentialsError struct {
+	AccessKeyID string
+	Expires     time.Time
+	SigningTime time.Time
+}
+
+func (e *ExpiredCredentialsError) Error() string {
+	return fmt.Sprintf("credentials of access key ID %q expired at %s", e.AccessKeyID, e.Expires.UTC().Format(TimeFormat))
+}
+
+func (e *ExpiredCredentialsError) category() ErrorCategory { return ErrorCategoryExpired }
+
+// PolicyError reports a POST upload that does not satisfy its policy.
+type PolicyError struct {
+	// Field is the form field, or "file" for the file size.
+	Field string
+
+	// Reason describes the violation.
+	Reason string
+}
+
+func (e *PolicyError) Error() string {
+	if e.Field == postFieldFile {
+		return "POST file size " + e.Reason
+	}
+	return fmt.Sprintf("POST form field %q %s", e.Field, e.Reason)
+}
+
+func (e *PolicyError) category() ErrorCategory { return ErrorCategoryPolicy }
@@ -0,0 +1,162 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		config Config
+		field  string
+	}{
+		{Config{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, "Region"},
+		{Config{Region: "us-east-1", SecretAccessKey: "SECRET"}, "AccessKeyID"},
+		{Config{Region: "us-east-1", AccessKeyID: "AKID"}, "SecretAccessKey"},
+	}
+	for _, tt := range tests {
+		_, err := NewSigner(tt.config)
+		var configErr *ConfigError
+		if !errors.As(err, &configErr) || configErr.Field != tt.field {
+			t.Errorf("NewSigner: expected *ConfigError for %s, got %v", tt.field, err)
+		}
+		if ErrorCategoryOf(err) != ErrorCategoryConfig {
+			t.Errorf("expected config category for %v", err)
+		}
+	}
+
+	_, err := NewScopedSigner(Config{Region: "eu-west-1"}, ScopedKey{
+		AccessKeyID: "AKID",
+		Date:        "20240115",
+		Region:      "us-east-1",
+		Service:     "s3",
+		Key:         make([]byte, 32),
+	})
+	var configErr *ConfigError
+	if !errors.As(err, &configErr) || configErr.Field != "Region" {
+		t.Errorf("NewScopedSigner: expected *ConfigError for Region, got %v", err)
+	}
+}
+
+func TestSigningErrors(t *testing.T) {
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	s, _ := NewSigner(testConfig)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if err := s.SignHTTP(req, "", signingTime); !errors.Is(err, ErrMissingPayloadHash) {
+		t.Errorf("SignHTTP: expected ErrMissingPayloadHash, got %v", err)
+	}
+	if _, _, err := s.PresignHTTP(req, "", signingTime); !errors.Is(err, ErrMissingPayloadHash) {
+		t.Errorf("PresignHTTP: expected ErrMissingPayloadHash, got %v", err)
+	}
+
+	config := testConfig
+	config.Expires = signingTime
+	expiring, _ := NewSigner(config)
+	var expiredErr *ExpiredCredentialsError
+	if err := expiring.SignHTTP(req, EmptyStringSHA256, signingTime.Add(-time.Second)); err != nil {
+		t.Errorf("SignHTTP before expiry failed: %v", err)
+	}
+	if err := expiring.SignHTTP(req, EmptyStringSHA256, signingTime); !errors.As(err, &expiredErr) {
+		t.Errorf("SignHTTP: expected *ExpiredCredentialsError, got %v", err)
+	} else if !expiredErr.Expires.Equal(signingTime) || ErrorCategoryOf(err) != ErrorCategoryExpired {
+		t.Errorf("unexpected %+v", expiredErr)
+	}
+	if _, _, err := expiring.PresignHTTP(req, UnsignedPayload, signingTime.Add(time.Hour)); !errors.As(err, &expiredErr) {
+		t.Errorf("PresignHTTP: expected *ExpiredCredentialsError, got %v", err)
+	}
+
+	remote, _ := NewSigner(Config{Region: "us-east-1", AccessKeyID: "AKID", KeyDeriver: &remoteKeyDeriver{}})
+	if err := remote.SignHTTP(req, EmptyStringSHA256, signingTime); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("SignHTTP: expected ErrNoSigningKey, got %v", err)
+	}
+
+	s.Close()
+	if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); !errors.Is(err, ErrClosed) {
+		t.Errorf("SignHTTP after Close: expected ErrClosed, got %v", err)
+	}
+}
+
+func TestVerificationErrors(t *testing.T) {
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	s, _ := NewSigner(testConfig)
+	v, _ := NewVerifier(testConfig)
+
+	signed := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("SignHTTP failed: %v", err)
+		}
+		return req
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if err := v.VerifyHTTP(req, EmptyStringSHA256, signingTime); !errors.Is(err, ErrMissingAuthorization) {
+		t.Errorf("expected ErrMissingAuthorization, got %v", err)
+	}
+
+	var skewErr *ClockSkewError
+	if err := v.VerifyHTTP(signed(), EmptyStringSHA256, signingTime.Add(time.Hour)); !errors.As(err, &skewErr) {
+		t.Errorf("expected *ClockSkewError, got %v", err)
+	}
+
+	if err := v.VerifyHTTP(signed(), UnsignedPayload, signingTime); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("expected ErrSignatureMismatch, got %v", err)
+	}
+
+	other := testConfig
+	other.Region = "eu-west-1"
+	otherVerifier, _ := NewVerifier(other)
+	var credErr *CredentialError
+	if err := otherVerifier.VerifyHTTP(signed(), EmptyStringSHA256, signingTime); !errors.As(err, &credErr) {
+		t.Errorf("expected *CredentialError, got %v", err)
+	} else if credErr.Field != "Region" || credErr.Value != "us-east-1" || credErr.Expected != "eu-west-1" {
+		t.Errorf("unexpected %+v", credErr)
+	}
+
+	other = testConfig
+	other.SessionToken = "TOKEN"
+	tokenVerifier, _ := NewVerifier(other)
+	if err := tokenVerifier.VerifyHTTP(signed(), EmptyStringSHA256, signingTime); !errors.Is(err, ErrSecurityTokenMismatch) {
+		t.Errorf("expected ErrSecurityTokenMismatch, got %v", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "https://bucket.example.com/key?X-Amz-Expires=60", nil)
+	signedURL, _, err := s.PresignHTTP(req, UnsignedPayload, signingTime)
+	if err != nil {
+		t.Fatalf("PresignHTTP failed: %v", err)
+	}
+	req, _ = http.NewRequest(http.MethodGet, signedURL, nil)
+	if err := v.VerifyPresignedHTTP(req, UnsignedPayload, signingTime.Add(2*time.Minute)); !errors.Is(err, ErrPresignExpired) {
+		t.Errorf("expected ErrPresignExpired, got %v", err)
+	}
+}
+
+func TestErrorCategoryOf(t *testing.T) {
+	tests := []struct {
+		err  error
+		want ErrorCategory
+	}{
+		{nil, ErrorCategoryNone},
+		{errors.New("other"), ErrorCategoryRequest},
+		{ErrMissingPayloadHash, ErrorCategoryRequest},
+		{fmt.Errorf("%w %q", ErrMalformedCredential, "AKID"), ErrorCategoryRequest},
+		{&ConfigError{Field: "Region", Reason: "is required"}, ErrorCategoryConfig},
+		{&CredentialError{Field: "AccessKeyID", Value: "A", Expected: "B"}, ErrorCategoryCredential},
+		{&ScopeError{KeyScope: "20240115/us-east-1/s3", Scope: "20240116/us-east-1/s3"}, ErrorCategoryCredential},
+		{fmt.Errorf("%w for 20240115/us-east-1/s3", ErrNoSigningKey), ErrorCategoryCredential},
+		{&ClockSkewError{}, ErrorCategoryExpired},
+		{&ExpiredCredentialsError{}, ErrorCategoryExpired},
+		{ErrPresignExpired, ErrorCategoryExpired},
+		{fmt.Errorf("verify: %w", ErrSignatureMismatch), ErrorCategorySignature},
+		{&PolicyError{Field: "key", Reason: "does not match policy"}, ErrorCategoryPolicy},
+	}
+	for _, tt := range tests {
+		if got := ErrorCategoryOf(tt.err); got != tt.want {
+			t.Errorf("ErrorCategoryOf(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
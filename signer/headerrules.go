@@ -0,0 +1,98 @@
+package signer
+
+// HeaderRules adjusts which headers a Signer signs and which it hoists
+// into the query when presigning, on top of the package rules
+// IgnoredHeaders and AllowedQueryHoisting. Names are matched in their
+// canonical form, e.g. "X-Forwarded-For". The zero value uses the package
+// rules unchanged.
+type HeaderRules struct {
+	// Ignored headers are not signed, e.g. headers added by proxies.
+	Ignored Rule
+
+	// Signed, if set, restricts signing to the headers it accepts.
+	// Headers with the profile's parameter prefix, such as X-Amz-Date and
+	// X-Amz-Content-Sha256, are always signed, as is Host, and
+	// Content-Length unless Minimal is set.
+	Signed Rule
+
+	// NoHoist headers are never hoisted into the query when presigning.
+	NoHoist Rule
+
+	// Minimal signs only Host, headers with the profile's parameter
+	// prefix, Content-Type, Content-Md5 and the headers accepted by
+	// Signed, leaving headers that intermediaries may rewrite unsigned.
+	// Content-Length is signed only when Signed accepts it.
+	Minimal bool
+}
+
+// minimalSignedHeaders are signed in Minimal mode in addition to those
+// with the profile's parameter prefix.
+var minimalSignedHeaders = MapRule{
+	"Content-Type": struct{}{},
+	"Content-Md5":  struct{}{},
+}
+
+// canonicalRule applies a Rule to canonical header names, as BuildQuery
+// may leave header names lower cased.
+type canonicalRule struct {
+	Rule
+}
+
+// IsValid canonicalizes value and checks it against the wrapped Rule.
+func (c canonicalRule) IsValid(value string) bool {
+	return c.Rule.IsValid(CanonicalizeHeaderKey(value))
+}
+
+// NewMapRule creates a MapRule matching the given header names, which
+// are stored in canonical form.
+func NewMapRule(names ...string) MapRule {
+	m := make(MapRule, len(names))
+	for _, name := range names {
+		m[CanonicalizeHeaderKey(name)] = struct{}{}
+	}
+	return m
+}
+
+// signRule returns the Rule selecting the headers to sign. The package
+// rules are read once, so a Signer is unaffected by later reassignment of
+// the package variables.
+func (r HeaderRules) signRule(profile Profile) Rule {
+	rules := InclusiveRules{IgnoredHeaders}
+	if r.Ignored != nil {
+		rules = append(rules, ExcludeList{canonicalRule{r.Ignored}})
+	}
+	switch {
+	case r.Minimal:
+		signed := Rules{canonicalRule{minimalSignedHeaders}, Patterns{profile.orDefault().ParamPrefix}}
+		if r.Signed != nil {
+			signed = append(signed, canonicalRule{r.Signed})
+		}
+		rules = append(rules, signed)
+	case r.Signed != nil:
+		rules = append(rules, Rules{
+			canonicalRule{r.Signed},
+			Patterns{profile.orDefault().ParamPrefix},
+		})
+	}
+	return rules
+}
+
+// signContentLength reports whether Content-Length is signed.
+func (r HeaderRules) signContentLength() bool {
+	if !r.Minimal {
+		return true
+	}
+	return r.Signed != nil && canonicalRule{r.Signed}.IsValid("Content-Length")
+}
+
+// hoistRule returns the Rule selecting the headers to hoist into the
+// query when presigning.
+func (r HeaderRules) hoistRule() Rule {
+	if r.NoHoist == nil {
+		return AllowedQueryHoisting
+	}
+	return InclusiveRules{
+		AllowedQueryHoisting,
+		ExcludeList{canonicalRule{r.NoHoist}},
+	}
+}
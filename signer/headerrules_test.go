@@ -0,0 +1,136 @@
+package signer
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHeaderRulesIgnored(t *testing.T) {
+	config := testConfig
+	config.HeaderRules.Ignored = NewMapRule("x-forwarded-for", "X-Request-Id")
+	signer, _ := NewSigner(config)
+	verifier, _ := NewVerifier(config)
+
+	req, payloadHash := buildTestRequest("GET", "https://example.com/bucket/key", "")
+	req.Header.Set("X-Forwarded-For", "10.0.0.1")
+	req.Header.Set("X-Request-Id", "abc")
+	req.Header.Set("X-Custom", "value")
+	if err := signer.SignHTTP(req, payloadHash, verifyTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	auth := req.Header.Get(AuthorizationHeader)
+	if strings.Contains(auth, "x-forwarded-for") || strings.Contains(auth, "x-request-id") {
+		t.Errorf("expected ignored headers to be unsigned, got %s", auth)
+	}
+	if !strings.Contains(auth, "x-custom") {
+		t.Errorf("expected other headers to be signed, got %s", auth)
+	}
+
+	// A proxy may rewrite the ignored header.
+	received := serverSide(t, req)
+	received.Header.Set("X-Forwarded-For", "10.0.0.1, 192.168.0.1")
+	if err := verifier.VerifyHTTP(received, payloadHash, verifyTime); err != nil {
+		t.Errorf("expected request to verify, got %v", err)
+	}
+}
+
+func TestHeaderRulesSigned(t *testing.T) {
+	config := testConfig
+	config.HeaderRules.Signed = NewMapRule("Content-Type")
+	signer, _ := NewSigner(config)
+
+	req, payloadHash := buildTestRequest("PUT", "https://example.com/bucket/key", "data")
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Custom", "value")
+	req.Header.Set(ContentSHAKey, payloadHash)
+	if err := signer.SignHTTP(req, payloadHash, verifyTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	auth := req.Header.Get(AuthorizationHeader)
+	if !strings.Contains(auth, "SignedHeaders=content-length;content-type;host;x-amz-content-sha256;x-amz-date,") {
+		t.Errorf("unexpected signed headers in %s", auth)
+	}
+}
+
+func TestHeaderRulesNoHoist(t *testing.T) {
+	req, _ := buildTestRequest("GET", "https://example.com/bucket/key?X-Amz-Expires=60", "")
+	req.Header.Set("X-Amz-Foo", "bar")
+
+	// By default, X-Amz-Foo is hoisted into the query.
+	signer, _ := NewSigner(testConfig)
+	signedURL, signedHeaders, _ := signer.PresignHTTP(req, UnsignedPayload, verifyTime)
+	if u, _ := url.Parse(signedURL); u.Query().Get("X-Amz-Foo") != "bar" || signedHeaders.Get("X-Amz-Foo") != "" {
+		t.Errorf("expected X-Amz-Foo to be hoisted, got %s", signedURL)
+	}
+
+	config := testConfig
+	config.HeaderRules.NoHoist = NewMapRule("X-Amz-Foo")
+	signer, _ = NewSigner(config)
+	signedURL, signedHeaders, _ = signer.PresignHTTP(req, UnsignedPayload, verifyTime)
+	if u, _ := url.Parse(signedURL); u.Query().Has("X-Amz-Foo") || signedHeaders.Get("X-Amz-Foo") != "bar" {
+		t.Errorf("expected X-Amz-Foo to stay a signed header, got %s %v", signedURL, signedHeaders)
+	}
+}
+
+func TestHeaderRulesSnapshot(t *testing.T) {
+	signer, _ := NewSigner(testConfig)
+
+	saved := IgnoredHeaders
+	IgnoredHeaders = Rules{ExcludeList{NewMapRule("Authorization", "X-Custom")}}
+	defer func() { IgnoredHeaders = saved }()
+
+	req, payloadHash := buildTestRequest("GET", "https://example.com/bucket/key", "")
+	req.Header.Set("X-Custom", "value")
+	signer.SignHTTP(req, payloadHash, time.Unix(0, 0))
+	if !strings.Contains(req.Header.Get(AuthorizationHeader), "x-custom") {
+		t.Error("expected existing signer to keep the rules it was created with")
+	}
+}
+
+func TestHeaderRulesMinimal(t *testing.T) {
+	config := testConfig
+	config.HeaderRules.Minimal = true
+	signer, _ := NewSigner(config)
+	verifier, _ := NewVerifier(config)
+
+	req, payloadHash := buildTestRequest("PUT", "https://example.com/bucket/key", "data")
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Content-MD5", "jXd/OF09/siBXSD3SWAm3A==")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("X-Envoy-Upstream", "mesh")
+	req.Header.Set("X-Amz-Meta-Source", "ingest")
+	req.Header.Set(ContentSHAKey, payloadHash)
+	if err := signer.SignHTTP(req, payloadHash, verifyTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	auth := req.Header.Get(AuthorizationHeader)
+	expected := "SignedHeaders=content-md5;content-type;host;x-amz-content-sha256;x-amz-date;x-amz-meta-source,"
+	if !strings.Contains(auth, expected) {
+		t.Errorf("expected %s in %s", expected, auth)
+	}
+
+	// Intermediaries may change unsigned headers, including the length.
+	received := serverSide(t, req)
+	received.Header.Set("Cache-Control", "max-age=0")
+	received.Header.Del("X-Envoy-Upstream")
+	received.ContentLength = -1
+	if err := verifier.VerifyHTTP(received, payloadHash, verifyTime); err != nil {
+		t.Errorf("expected request to verify, got %v", err)
+	}
+
+	// Named headers, including Content-Length, are signed as well.
+	config.HeaderRules.Signed = NewMapRule("Cache-Control", "Content-Length")
+	signer, _ = NewSigner(config)
+	req, payloadHash = buildTestRequest("PUT", "https://example.com/bucket/key", "data")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("X-Envoy-Upstream", "mesh")
+	signer.SignHTTP(req, payloadHash, verifyTime)
+	if !strings.Contains(req.Header.Get(AuthorizationHeader), "SignedHeaders=cache-control;content-length;host;x-amz-date,") {
+		t.Errorf("unexpected signed headers in %s", req.Header.Get(AuthorizationHeader))
+	}
+}
@@ -67,6 +67,8 @@ func (r InclusiveRules) IsValid(value string) bool {
 }
 
 // IgnoredHeaders lists headers that are ignored during signing.
+// Signers read IgnoredHeaders and AllowedQueryHoisting when created; use
+// Config.HeaderRules to adjust the rules of a single Signer.
 // Reference: AWS SDK v4 signer internal/v4/headers.go IgnoredHeaders
 var IgnoredHeaders = Rules{
 	ExcludeList{
@@ -270,6 +270,24 @@ func TestSanitizeHostForHeader(t *testing.T) {
 			host:     "example.com:443",
 			expected: "example.com",
 		},
+		{
+			name:     "default WS port",
+			url:      "ws://example.com:80/mqtt",
+			host:     "example.com:80",
+			expected: "example.com",
+		},
+		{
+			name:     "default WSS port",
+			url:      "wss://example.com:443/mqtt",
+			host:     "example.com:443",
+			expected: "example.com",
+		},
+		{
+			name:     "WSS with HTTP port",
+			url:      "wss://example.com:80/mqtt",
+			host:     "example.com:80",
+			expected: "example.com:80",
+		},
 		{
 			name:     "non-default port",
 			url:      "https://example.com:8080/path",
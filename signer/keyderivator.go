@@ -1,83 +1,201 @@
 package signer
 
 import (
-	"strings"
+	"context"
+	"fmt"
+	"sync/atomic"
 	"time"
 )
 
-// keyDerivator is an interface for deriving signing keys.
+// KeyDeriver derives the day-scoped signing key of a request. Set
+// Config.KeyDeriver to derive keys outside the process, e.g. in an HSM or
+// a KMS-backed service that holds the secret and returns only the signing
+// key, in which case secretAccessKey is Config.SecretAccessKey and may be
+// empty. A KeyDeriver returns nil when it cannot derive the key, which
+// fails the signing or verification. It must be safe for concurrent use
+// when Config.ThreadSafety is set.
 // Reference: AWS SDK v4 signer v4.go keyDerivator interface
-type keyDerivator interface {
+type KeyDeriver interface {
 	DeriveKey(accessKeyID, secretAccessKey, service, region string, signingTime SigningTime) []byte
 }
 
-// derivedKey represents a cached derived key.
-type derivedKey struct {
-	accessKeyID string
-	date        time.Time
-	key         []byte
+// deriveSigningKey derives a key with d, rejecting the nil key returned
+// by a KeyDeriver that cannot derive one and scopes outside a ScopedKey.
+// It reports whether the key was served from a SigningKeyDeriver cache,
+// and the derivation to o if o is not nil.
+func deriveSigningKey(ctx context.Context, o Observer, d KeyDeriver, accessKeyID, secretAccessKey, service, region string, signingTime SigningTime) (key []byte, cached bool, err error) {
+	if o != nil {
+		defer func(start time.Time) {
+			o.Observe(ctx, Observation{
+				Operation:     OperationDeriveKey,
+				Start:         start,
+				Duration:      time.Since(start),
+				Err:           err,
+				ErrorCategory: ErrorCategoryOf(err),
+				CachedKey:     cached,
+			})
+		}(time.Now())
+	}
+
+	if scoped, ok := d.(*scopedKeyDeriver); ok {
+		if err := scoped.check(accessKeyID, service, region, signingTime); err != nil {
+			return nil, false, err
+		}
+	}
+	if caching, ok := d.(*SigningKeyDeriver); ok {
+		key, cached = caching.derive(accessKeyID, secretAccessKey, service, region, signingTime)
+	} else {
+		key = d.DeriveKey(accessKeyID, secretAccessKey, service, region, signingTime)
+	}
+	if len(key) == 0 {
+		if caching, ok := d.(*SigningKeyDeriver); ok && caching.closed.Load() {
+			return nil, false, ErrClosed
+		}
+		return nil, false, fmt.Errorf("%w for %s/%s/%s", ErrNoSigningKey, signingTime.ShortTimeFormat(), region, service)
+	}
+	return key, cached, nil
 }
 
-// derivedKeyCacheInterface defines the interface for cache implementations.
-// Implementations may be thread-safe or not, depending on the use case.
-type derivedKeyCacheInterface interface {
-	get(key string, accessKeyID string, t time.Time) ([]byte, bool)
-	set(key string, accessKeyID string, t time.Time, k []byte)
+// KeyCache stores the keys derived by a SigningKeyDeriver.
+// Implementations may be thread-safe or not, depending on the use case;
+// use NewKeyCache to create one.
+type KeyCache interface {
+	get(key lookupKey) ([]byte, bool)
+	set(key lookupKey, k []byte)
+	stats() KeyCacheStats
+	wipe()
 }
 
-// lookupKey creates a cache key from service and region.
-func lookupKey(service, region string) string {
-	var b strings.Builder
-	b.Grow(len(region) + len(service) + 3)
-	b.WriteString(region)
-	b.WriteRune('/')
-	b.WriteString(service)
-	return b.String()
+// NewKeyCache creates a KeyCache of at most size keys, or
+// DefaultKeyCacheSize if size is not positive. With threadSafe set, the
+// cache can be used concurrently from multiple goroutines.
+func NewKeyCache(size int, threadSafe bool) KeyCache {
+	if threadSafe {
+		return newDerivedKeyCacheThr(size)
+	}
+	return newDerivedKeyCacheNoThr(size)
 }
 
+// lookupKey identifies a cached key by access key, region, service and
+// day, given as YYYYMMDD. It is a struct rather than a joined string so
+// that cache hits do not allocate.
+type lookupKey struct {
+	accessKeyID string
+	region      string
+	service     string
+	day         int
+}
+
+// newLookupKey creates the cache key of a derived key.
+func newLookupKey(accessKeyID, service, region string, t time.Time) lookupKey {
+	y, m, d := t.Date()
+	return lookupKey{
+		accessKeyID: accessKeyID,
+		region:      region,
+		service:     service,
+		day:         y*10000 + int(m)*100 + d,
+	}
+}
 
-// isSameDay checks if two times are on the same day.
-func isSameDay(t1, t2 time.Time) bool {
-	y1, m1, d1 := t1.Date()
-	y2, m2, d2 := t2.Date()
-	return y1 == y2 && m1 == m2 && d1 == d2
+// newKeyDerivator creates a caching key derivator whose cache matches the
+// thread safety and size requested by config. Keys are derived by
+// config.KeyDeriver if set.
+func newKeyDerivator(config Config) KeyDeriver {
+	deriver := NewSigningKeyDeriver(NewKeyCache(config.KeyCacheSize, config.ThreadSafety))
+	deriver.profile = config.Profile
+	deriver.source = config.KeyDeriver
+	return deriver
 }
 
 // SigningKeyDeriver derives signing keys with caching.
 // Thread safety depends on the cache implementation provided.
 // Reference: AWS SDK v4 signer internal/v4/cache.go
 type SigningKeyDeriver struct {
-	cache derivedKeyCacheInterface
+	cache   KeyCache
+	profile Profile
+	source  KeyDeriver
+	closed  atomic.Bool
 }
 
 // NewSigningKeyDeriver creates a new SigningKeyDeriver with the provided cache.
-func NewSigningKeyDeriver(cache derivedKeyCacheInterface) *SigningKeyDeriver {
+func NewSigningKeyDeriver(cache KeyCache) *SigningKeyDeriver {
 	return &SigningKeyDeriver{
 		cache: cache,
 	}
 }
 
+// NewCachingKeyDeriver creates a SigningKeyDeriver that caches the keys
+// derived by source, such as a remote KeyDeriver, in cache.
+func NewCachingKeyDeriver(source KeyDeriver, cache KeyCache) *SigningKeyDeriver {
+	return &SigningKeyDeriver{
+		cache:  cache,
+		source: source,
+	}
+}
+
 // DeriveKey derives a signing key from credentials.
-// Implements the SigV4 key derivation algorithm:
-//   - kDate = HMAC-SHA256("AWS4" + secret, date)
+// Implements the SigV4 key derivation algorithm, with the key prefix and
+// terminator of the Signer's Profile ("AWS4" and "aws4_request" for the
+// default AWSProfile), unless the key is derived by a source KeyDeriver:
+//   - kDate = HMAC-SHA256(prefix + secret, date)
 //   - kRegion = HMAC-SHA256(kDate, region)
 //   - kService = HMAC-SHA256(kRegion, service)
-//   - kSigning = HMAC-SHA256(kService, "aws4_request")
+//   - kSigning = HMAC-SHA256(kService, terminator)
 //
-// Keys are cached per day/region/service/accessKeyID combination.
+// Keys are cached per day/region/service/accessKeyID combination, with
+// least recently used keys evicted once the cache is full.
 // Thread safety depends on the cache implementation provided to NewSigningKeyDeriver.
+// The returned key is a copy that the caller may retain.
 // Reference: AWS SigV4 spec and AWS SDK v4 signer internal/v4/cache.go
 func (k *SigningKeyDeriver) DeriveKey(accessKeyID, secretAccessKey, service, region string, signingTime SigningTime) []byte {
-	cacheKey := lookupKey(service, region)
-	if key, ok := k.cache.get(cacheKey, accessKeyID, signingTime.Time); ok {
-		return key
+	key, _ := k.derive(accessKeyID, secretAccessKey, service, region, signingTime)
+	if key == nil {
+		return nil
 	}
+	return append([]byte(nil), key...)
+}
 
-	// Derive the key using HMAC-SHA256 chain
-	key := DeriveKey(secretAccessKey, service, region, signingTime)
+// derive returns the key of DeriveKey without copying it, and whether it
+// was cached. The key may be owned by the cache, which zeroes it on
+// eviction, so it must be used before the deriver is used again.
+func (k *SigningKeyDeriver) derive(accessKeyID, secretAccessKey, service, region string, signingTime SigningTime) ([]byte, bool) {
+	if k.closed.Load() {
+		return nil, false
+	}
+
+	cacheKey := newLookupKey(accessKeyID, service, region, signingTime.Time)
+	if key, ok := k.cache.get(cacheKey); ok {
+		return key, true
+	}
+
+	var key []byte
+	if k.source != nil {
+		key = k.source.DeriveKey(accessKeyID, secretAccessKey, service, region, signingTime)
+		if len(key) == 0 {
+			return nil, false
+		}
+		// The cache zeroes the keys it evicts, so it keeps its own copy.
+		key = append([]byte(nil), key...)
+	} else {
+		// Derive the key using HMAC-SHA256 chain
+		key = k.profile.orDefault().DeriveKey(secretAccessKey, service, region, signingTime)
+	}
 
 	// Cache the derived key
-	k.cache.set(cacheKey, accessKeyID, signingTime.Time, key)
+	k.cache.set(cacheKey, key)
+
+	return key, false
+}
+
+// Stats returns the hit, miss and eviction counts of the key cache.
+func (k *SigningKeyDeriver) Stats() KeyCacheStats {
+	return k.cache.stats()
+}
 
-	return key
+// Close zeroes the cached keys. DeriveKey returns nil once the deriver is
+// closed. The source KeyDeriver, if any, is not closed.
+func (k *SigningKeyDeriver) Close() error {
+	k.closed.Store(true)
+	k.cache.wipe()
+	return nil
 }
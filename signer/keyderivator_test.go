@@ -2,12 +2,14 @@ package signer
 
 import (
 	"encoding/hex"
+	"errors"
+	"net/http"
 	"testing"
 	"time"
 )
 
 func TestDeriveKey(t *testing.T) {
-	deriver := NewSigningKeyDeriver(newDerivedKeyCacheNoThr())
+	deriver := NewSigningKeyDeriver(newDerivedKeyCacheNoThr(0))
 
 	accessKeyID := "AKID"
 	secretAccessKey := "SECRET"
@@ -97,7 +99,7 @@ func TestDeriveKey(t *testing.T) {
 
 func TestDeriveKeyKnownValue(t *testing.T) {
 	// Test with known values to ensure correctness
-	deriver := NewSigningKeyDeriver(newDerivedKeyCacheNoThr())
+	deriver := NewSigningKeyDeriver(newDerivedKeyCacheNoThr(0))
 
 	secret := "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
 	service := "iam"
@@ -139,7 +141,7 @@ func TestDeriveKeyKnownValue(t *testing.T) {
 }
 
 func TestKeyDerivatorCache(t *testing.T) {
-	deriver := NewSigningKeyDeriver(newDerivedKeyCacheNoThr())
+	deriver := NewSigningKeyDeriver(newDerivedKeyCacheNoThr(0))
 
 	accessKeyID := "AKID"
 	secretAccessKey := "SECRET"
@@ -168,3 +170,98 @@ func TestKeyDerivatorCache(t *testing.T) {
 	}
 }
 
+
+// remoteKeyDeriver stands in for an HSM or KMS-backed service holding the
+// secret, counting the keys it derives.
+type remoteKeyDeriver struct {
+	secret string
+	calls  int
+}
+
+func (r *remoteKeyDeriver) DeriveKey(accessKeyID, secretAccessKey, service, region string, signingTime SigningTime) []byte {
+	r.calls++
+	if secretAccessKey != "" {
+		panic("secret passed to remote deriver")
+	}
+	if r.secret == "" {
+		return nil
+	}
+	return DeriveKey(r.secret, service, region, signingTime)
+}
+
+func TestConfigKeyDeriver(t *testing.T) {
+	remote := &remoteKeyDeriver{secret: "SECRET"}
+	s, err := NewSigner(Config{
+		Region:      "us-east-1",
+		AccessKeyID: "AKID",
+		KeyDeriver:  remote,
+	})
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+
+	v, err := NewVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("SignHTTP failed: %v", err)
+		}
+		if err := v.VerifyHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("VerifyHTTP failed: %v", err)
+		}
+	}
+
+	if remote.calls != 1 {
+		t.Errorf("expected derived key to be cached, got %d remote calls", remote.calls)
+	}
+}
+
+func TestConfigKeyDeriverNoKey(t *testing.T) {
+	remote := &remoteKeyDeriver{}
+	config := Config{
+		Region:      "us-east-1",
+		AccessKeyID: "AKID",
+		KeyDeriver:  remote,
+	}
+	s, err := NewSigner(config)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	err = s.SignHTTP(req, EmptyStringSHA256, signingTime)
+	if !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("expected ErrNoSigningKey, got %v", err)
+	}
+	if _, _, err := s.PresignHTTP(req, UnsignedPayload, signingTime); !errors.Is(err, ErrNoSigningKey) {
+		t.Errorf("PresignHTTP: expected ErrNoSigningKey, got %v", err)
+	}
+
+	// A verifier must not fall back to an empty key.
+	signed, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	good, _ := NewSigner(testConfig)
+	if err := good.SignHTTP(signed, EmptyStringSHA256, signingTime); err != nil {
+		t.Fatalf("SignHTTP failed: %v", err)
+	}
+	v, err := NewVerifier(config)
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	if err := v.VerifyHTTP(signed, EmptyStringSHA256, signingTime); err == nil {
+		t.Error("expected VerifyHTTP to fail without a signing key")
+	}
+
+	if remote.calls != 3 {
+		t.Errorf("expected failed derivations not to be cached, got %d remote calls", remote.calls)
+	}
+
+	if _, err := NewV2Signer(config); err == nil {
+		t.Error("expected NewV2Signer to require the secret")
+	}
+}
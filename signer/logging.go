@@ -0,0 +1,79 @@
+package signer
+
+import (
+	"context"
+	"log/slog"
+)
+
+// signaturePrefixLen is the number of signature characters logged, enough
+// to correlate log lines with requests without logging the signature.
+const signaturePrefixLen = 8
+
+// signingEvent describes a signing or verification for debug logging. It
+// holds no secrets; the signature is truncated when logged.
+type signingEvent struct {
+	Method        string
+	Host          string
+	AccessKeyID   string
+	Scope         string
+	SignedHeaders string
+	PayloadHash   string
+	Signature     string
+	Presigned     bool
+	CachedKey     bool
+}
+
+// debugEnabled reports whether logger is set and logs debug messages, so
+// that events are only built when they will be logged.
+func debugEnabled(ctx context.Context, logger *slog.Logger) bool {
+	return logger != nil && logger.Enabled(ctx, slog.LevelDebug)
+}
+
+// log writes the event to logger at debug level, with err if not nil.
+func (e *signingEvent) log(ctx context.Context, logger *slog.Logger, msg string, err error) {
+	keyCache := "miss"
+	if e.CachedKey {
+		keyCache = "hit"
+	}
+	attrs := []slog.Attr{
+		slog.String("method", e.Method),
+		slog.String("host", e.Host),
+		slog.String("access_key_id", e.AccessKeyID),
+		slog.String("scope", e.Scope),
+		slog.String("signed_headers", e.SignedHeaders),
+		slog.String("payload", payloadMode(e.PayloadHash)),
+		slog.Bool("presigned", e.Presigned),
+		slog.String("key_cache", keyCache),
+	}
+	if e.Signature != "" {
+		attrs = append(attrs, slog.String("signature", signaturePrefix(e.Signature)))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, msg, attrs...)
+}
+
+// payloadMode describes how the payload was signed.
+func payloadMode(payloadHash string) string {
+	switch payloadHash {
+	case "":
+		return ""
+	case UnsignedPayload:
+		return "unsigned"
+	case StreamingEventsPayload:
+		return "streaming"
+	case EmptyStringSHA256:
+		return "empty"
+	default:
+		return "signed"
+	}
+}
+
+// signaturePrefix returns the start of a hex signature followed by "...".
+func signaturePrefix(signature string) string {
+	if len(signature) <= signaturePrefixLen {
+		return signature
+	}
+	return signature[:signaturePrefixLen] + "..."
+}
@@ -0,0 +1,144 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// logRecords decodes the JSON log lines in buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid log line %q: %v", line, err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestSignerLogging(t *testing.T) {
+	var buf bytes.Buffer
+	config := testConfig
+	config.SessionToken = "SESSIONTOKEN"
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	s, err := NewSigner(config)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	v, err := NewVerifier(config)
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	var signatures []string
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest(http.MethodPut, "https://bucket.example.com/key", nil)
+		req.Header.Set("Content-Type", "text/plain")
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("SignHTTP failed: %v", err)
+		}
+		auth := req.Header.Get(AuthorizationHeader)
+		signatures = append(signatures, auth[strings.LastIndex(auth, "=")+1:])
+		if i == 1 {
+			req.Header.Set("Content-Type", "text/html")
+			if err := v.VerifyHTTP(req, EmptyStringSHA256, signingTime); err == nil {
+				t.Fatal("expected VerifyHTTP to fail")
+			}
+		}
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if _, _, err := s.PresignHTTP(req, UnsignedPayload, signingTime); err != nil {
+		t.Fatalf("PresignHTTP failed: %v", err)
+	}
+
+	out := buf.String()
+	for _, secret := range append(signatures, config.SecretAccessKey, config.SessionToken) {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q:\n%s", secret, out)
+		}
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 4 {
+		t.Fatalf("expected 4 log records, got %d:\n%s", len(records), out)
+	}
+	want := []map[string]any{
+		{"msg": "signed request", "key_cache": "miss", "payload": "empty", "presigned": false},
+		{"msg": "signed request", "key_cache": "hit"},
+		{"msg": "verified request", "key_cache": "miss", "error": "signature does not match"},
+		{"msg": "presigned request", "key_cache": "hit", "payload": "unsigned", "presigned": true},
+	}
+	for i, fields := range want {
+		r := records[i]
+		if r["level"] != "DEBUG" || r["method"] == "" || r["host"] != "bucket.example.com" {
+			t.Errorf("record %d: unexpected level, method or host: %v", i, r)
+		}
+		if r["scope"] != "20240115/us-east-1/s3/aws4_request" {
+			t.Errorf("record %d: scope = %v", i, r["scope"])
+		}
+		for k, val := range fields {
+			if r[k] != val {
+				t.Errorf("record %d: %s = %v, want %v", i, k, r[k], val)
+			}
+		}
+	}
+	if got := records[0]["signed_headers"]; got != "content-type;host;x-amz-date;x-amz-security-token" {
+		t.Errorf("signed_headers = %v", got)
+	}
+	if got := records[0]["signature"].(string); got != signatures[0][:signaturePrefixLen]+"..." {
+		t.Errorf("signature = %q, want prefix of %q", got, signatures[0])
+	}
+}
+
+func TestV2SignerLogging(t *testing.T) {
+	var buf bytes.Buffer
+	config := v2Config
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s, _ := NewV2Signer(config)
+
+	req, _ := http.NewRequest(http.MethodGet, "https://s3.amazonaws.com/johnsmith/photos/puppy.jpg", nil)
+	if err := s.SignHTTP(req, time.Date(2007, 3, 27, 19, 36, 42, 0, time.UTC)); err != nil {
+		t.Fatalf("SignHTTP failed: %v", err)
+	}
+	if _, err := s.PresignHTTP(req, time.Unix(1175139620, 0)); err != nil {
+		t.Fatalf("PresignHTTP failed: %v", err)
+	}
+
+	out := buf.String()
+	auth := req.Header.Get(AuthorizationHeader)
+	if signature := auth[strings.LastIndex(auth, ":")+1:]; strings.Contains(out, signature) || strings.Contains(out, config.SecretAccessKey) {
+		t.Errorf("log contains the signature or secret:\n%s", out)
+	}
+	records := logRecords(t, &buf)
+	if len(records) != 2 || records[0]["msg"] != "signed request" || records[1]["msg"] != "presigned request" {
+		t.Fatalf("unexpected log records:\n%s", out)
+	}
+	if records[0]["access_key_id"] != config.AccessKeyID || records[0]["host"] != "s3.amazonaws.com" {
+		t.Errorf("unexpected record %v", records[0])
+	}
+}
+
+func TestSignerLoggingDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	config := testConfig
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+
+	s, _ := NewSigner(config)
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if err := s.SignHTTP(req, EmptyStringSHA256, time.Now()); err != nil {
+		t.Fatalf("SignHTTP failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no logs above debug level, got %s", buf.String())
+	}
+}
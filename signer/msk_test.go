@@ -0,0 +1,129 @@
+package signer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+var mskConfig = Config{
+	Region:          "us-east-1",
+	AccessKeyID:     "AKID",
+	SecretAccessKey: "SECRET",
+	SessionToken:    "SESSION",
+}
+
+func TestMSKToken(t *testing.T) {
+	signingTime := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	generator, err := NewMSKTokenGenerator(mskConfig)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+	generator.UserAgent = "processor/1.0"
+
+	token, err := generator.Token(signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatalf("token is not base64url: %v", err)
+	}
+	u, _ := url.Parse(string(data))
+	if u.Host != "kafka.us-east-1.amazonaws.com" {
+		t.Errorf("unexpected host %q", u.Host)
+	}
+	query := u.Query()
+	expected := map[string]string{
+		"Action":            MSKConnectAction,
+		"User-Agent":        "processor/1.0",
+		AmzExpiresKey:       "900",
+		AmzSecurityTokenKey: "SESSION",
+		AmzCredentialKey:    "AKID/20240301/us-east-1/kafka-cluster/aws4_request",
+	}
+	for k, v := range expected {
+		if query.Get(k) != v {
+			t.Errorf("expected %s=%q, got %q", k, v, query.Get(k))
+		}
+	}
+
+	verifier, err := NewMSKVerifier(mskConfig)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	if err := verifier.VerifyToken(token, signingTime.Add(10*time.Minute)); err != nil {
+		t.Errorf("expected token to verify, got %v", err)
+	}
+	if err := verifier.VerifyToken(token, signingTime.Add(16*time.Minute)); err == nil {
+		t.Error("expected token to expire after 15 minutes")
+	}
+
+	other := mskConfig
+	other.SessionToken = "OTHER"
+	otherVerifier, _ := NewMSKVerifier(other)
+	if err := otherVerifier.VerifyToken(token, signingTime); err == nil {
+		t.Error("expected error for another session token")
+	}
+}
+
+func TestMSKAuthPayload(t *testing.T) {
+	signingTime := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	broker := "b-1.cluster.abc123.c2.kafka.us-east-1.amazonaws.com"
+	generator, _ := NewMSKTokenGenerator(mskConfig)
+
+	payload, err := generator.AuthPayload(broker, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	var fields map[string]string
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		t.Fatalf("payload is not JSON: %v", err)
+	}
+	expected := map[string]string{
+		"version":              MSKPayloadVersion,
+		"host":                 broker,
+		"user-agent":           DefaultMSKUserAgent,
+		"action":               MSKConnectAction,
+		"x-amz-algorithm":      SigningAlgorithm,
+		"x-amz-credential":     "AKID/20240301/us-east-1/kafka-cluster/aws4_request",
+		"x-amz-date":           "20240301T000000Z",
+		"x-amz-security-token": "SESSION",
+		"x-amz-signedheaders":  "host",
+		"x-amz-expires":        "900",
+	}
+	for k, v := range expected {
+		if fields[k] != v {
+			t.Errorf("expected %s=%q, got %q", k, v, fields[k])
+		}
+	}
+	if fields["x-amz-signature"] == "" {
+		t.Error("expected x-amz-signature")
+	}
+
+	verifier, _ := NewMSKVerifier(mskConfig)
+	if err := verifier.VerifyAuthPayload(payload, broker, signingTime.Add(time.Minute)); err != nil {
+		t.Errorf("expected payload to verify, got %v", err)
+	}
+	if err := verifier.VerifyAuthPayload(payload, "b-2.cluster.abc123.c2.kafka.us-east-1.amazonaws.com", signingTime); err == nil {
+		t.Error("expected error for another broker")
+	}
+
+	fields["action"] = "kafka-cluster:AlterCluster"
+	tampered, _ := json.Marshal(fields)
+	if err := verifier.VerifyAuthPayload(tampered, broker, signingTime); !errors.Is(err, ErrInvalidMSKToken) {
+		t.Errorf("expected ErrInvalidMSKToken for tampered action, got %v", err)
+	}
+	if err := verifier.VerifyToken("not base64!", signingTime); !errors.Is(err, ErrInvalidMSKToken) {
+		t.Errorf("expected ErrInvalidMSKToken for malformed token, got %v", err)
+	}
+
+	var configErr *ConfigError
+	if _, err := generator.AuthPayload("", signingTime); !errors.As(err, &configErr) || configErr.Field != "BrokerHost" {
+		t.Errorf("expected *ConfigError for BrokerHost, got %v", err)
+	}
+}
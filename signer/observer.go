@@ -0,0 +1,146 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Operation names an operation reported to an Observer.
+type Operation string
+
+const (
+	// OperationSign is the signing of a request by SignHTTP or
+	// SignStreamHTTP.
+	OperationSign Operation = "sign"
+
+	// OperationPresign is the presigning of a request by PresignHTTP or
+	// PresignWebSocket.
+	OperationPresign Operation = "presign"
+
+	// OperationSignEvent is the signing of an event stream message.
+	OperationSignEvent Operation = "sign_event"
+
+	// OperationDeriveKey is the derivation, or cache lookup, of a signing
+	// key.
+	OperationDeriveKey Operation = "derive_key"
+
+	// OperationVerify is the verification of a request by VerifyHTTP.
+	OperationVerify Operation = "verify"
+
+	// OperationVerifyPresigned is the verification of a request by
+	// VerifyPresignedHTTP.
+	OperationVerifyPresigned Operation = "verify_presigned"
+
+	// OperationVerifyPOST is the verification of a POST upload by
+	// PostPolicyVerifier.
+	OperationVerifyPOST Operation = "verify_post"
+)
+
+// ErrorCategory classifies the error of an operation for metrics.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryNone is the category of a successful operation.
+	ErrorCategoryNone ErrorCategory = ""
+
+	// ErrorCategoryRequest covers missing or malformed request and
+	// signature parameters.
+	ErrorCategoryRequest ErrorCategory = "request"
+
+	// ErrorCategoryConfig covers invalid Config fields.
+	ErrorCategoryConfig ErrorCategory = "config"
+
+	// ErrorCategoryCredential covers unknown access keys, credential scopes
+	// that do not match, mismatched session tokens and signing keys that
+	// cannot be derived.
+	ErrorCategoryCredential ErrorCategory = "credential"
+
+	// ErrorCategoryExpired covers signing times outside the allowed clock
+	// skew, expired credentials and expired presigned requests and POST
+	// policies.
+	ErrorCategoryExpired ErrorCategory = "expired"
+
+	// ErrorCategorySignature covers signatures that do not match.
+	ErrorCategorySignature ErrorCategory = "signature"
+
+	// ErrorCategoryPolicy covers POST uploads that do not satisfy their
+	// policy.
+	ErrorCategoryPolicy ErrorCategory = "policy"
+)
+
+// Observation describes a completed operation.
+type Observation struct {
+	// Operation is the operation observed.
+	Operation Operation
+
+	// Start is the time the operation started.
+	Start time.Time
+
+	// Duration is the time the operation took.
+	Duration time.Duration
+
+	// Err is the error of the operation, if any.
+	Err error
+
+	// ErrorCategory is the category of Err, as by ErrorCategoryOf.
+	ErrorCategory ErrorCategory
+
+	// CachedKey reports, for OperationDeriveKey, whether the key was
+	// served from the key cache.
+	CachedKey bool
+}
+
+// Observer receives the observations of a Signer or verifier, to be
+// bridged to a metrics or tracing system. The context is that of the
+// request, or context.Background() for event stream messages. Observe is
+// called synchronously and must be safe for concurrent use when
+// Config.ThreadSafety is set. A nil Observer, the default, observes
+// nothing and costs nothing.
+type Observer interface {
+	Observe(ctx context.Context, obs Observation)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(ctx context.Context, obs Observation)
+
+// Observe calls f(ctx, obs).
+func (f ObserverFunc) Observe(ctx context.Context, obs Observation) {
+	f(ctx, obs)
+}
+
+// observeSince reports op, started at start, with the error *err to o. It
+// is meant to be deferred.
+func observeSince(ctx context.Context, o Observer, op Operation, start time.Time, err *error) {
+	o.Observe(ctx, Observation{
+		Operation:     op,
+		Start:         start,
+		Duration:      time.Since(start),
+		Err:           *err,
+		ErrorCategory: ErrorCategoryOf(*err),
+	})
+}
+
+// requestContext returns the context of req, or context.Background() if
+// req is nil, which fails validation.
+func requestContext(req *http.Request) context.Context {
+	if req == nil {
+		return context.Background()
+	}
+	return req.Context()
+}
+
+// ErrorCategoryOf returns the category of an error returned by this
+// package: ErrorCategoryNone for nil, and ErrorCategoryRequest for errors
+// of no other category.
+func ErrorCategoryOf(err error) ErrorCategory {
+	if err == nil {
+		return ErrorCategoryNone
+	}
+	var c categorized
+	if errors.As(err, &c) {
+		return c.category()
+	}
+	return ErrorCategoryRequest
+}
@@ -0,0 +1,70 @@
+package signer
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestObserver(t *testing.T) {
+	var observed []Observation
+	config := testConfig
+	config.Observer = ObserverFunc(func(ctx context.Context, obs Observation) {
+		if ctx == nil || obs.Start.IsZero() || obs.Duration < 0 {
+			t.Errorf("unexpected context or timing in %+v", obs)
+		}
+		observed = append(observed, obs)
+	})
+
+	s, err := NewSigner(config)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	v, err := NewVerifier(config)
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	for i := 0; i < 2; i++ {
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Fatalf("SignHTTP failed: %v", err)
+		}
+	}
+	if err := v.VerifyHTTP(req, EmptyStringSHA256, signingTime.Add(time.Hour)); err == nil {
+		t.Fatal("expected VerifyHTTP to fail on clock skew")
+	}
+	req.Header.Set("X-Amz-Content-Sha256", UnsignedPayload)
+	if err := v.VerifyHTTP(req, UnsignedPayload, signingTime); err == nil {
+		t.Fatal("expected VerifyHTTP to fail on payload hash")
+	}
+	if err := s.SignHTTP(req, "", signingTime); err == nil {
+		t.Fatal("expected SignHTTP to fail without payload hash")
+	}
+
+	want := []Observation{
+		{Operation: OperationDeriveKey},
+		{Operation: OperationSign},
+		{Operation: OperationDeriveKey, CachedKey: true},
+		{Operation: OperationSign},
+		{Operation: OperationVerify, ErrorCategory: ErrorCategoryExpired},
+		{Operation: OperationDeriveKey},
+		{Operation: OperationVerify, ErrorCategory: ErrorCategorySignature},
+		{Operation: OperationSign, ErrorCategory: ErrorCategoryRequest},
+	}
+	if len(observed) != len(want) {
+		t.Fatalf("got %d observations, want %d: %+v", len(observed), len(want), observed)
+	}
+	for i, w := range want {
+		got := observed[i]
+		if got.Operation != w.Operation || got.ErrorCategory != w.ErrorCategory || got.CachedKey != w.CachedKey {
+			t.Errorf("observation %d = %s/%q cached=%v, want %s/%q cached=%v",
+				i, got.Operation, got.ErrorCategory, got.CachedKey, w.Operation, w.ErrorCategory, w.CachedKey)
+		}
+		if (got.Err != nil) != (w.ErrorCategory != ErrorCategoryNone) {
+			t.Errorf("observation %d: unexpected error %v", i, got.Err)
+		}
+	}
+}
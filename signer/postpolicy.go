@@ -0,0 +1,481 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// POST policy form field names.
+// Reference: Amazon S3 API Reference, "Browser-Based Uploads Using POST"
+// The signing fields, such as x-amz-signature, are named after the
+// Profile's parameters, as in postSigningFields.
+const (
+	postFieldPolicy = "policy"
+	postFieldKey    = "key"
+	postFieldBucket = "bucket"
+	postFieldFile   = "file"
+
+	// postIgnorePrefix marks fields that need not appear in the policy.
+	postIgnorePrefix = "x-ignore-"
+
+	// maxPostFormSize bounds the total size of the non-file form fields.
+	// S3 rejects POST forms whose fields exceed 20 KB.
+	maxPostFormSize = 20 << 10
+)
+
+// POST policy condition operators.
+const (
+	PolicyConditionEq                 = "eq"
+	PolicyConditionStartsWith         = "starts-with"
+	PolicyConditionContentLengthRange = "content-length-range"
+)
+
+// PostPolicyCondition is a single condition from a POST policy document.
+// Field is the lower-cased form field name without the leading "$".
+// Min and Max are only set for content-length-range conditions.
+type PostPolicyCondition struct {
+	Operator string
+	Field    string
+	Value    string
+	Min      int64
+	Max      int64
+}
+
+// PostPolicy is a decoded POST policy document.
+type PostPolicy struct {
+	Expiration time.Time
+	Conditions []PostPolicyCondition
+}
+
+// PostUpload is a verified browser-based POST upload.
+// File streams the file part directly from the request body and enforces
+// any content-length-range condition while it is read, so it must be
+// consumed before the HTTP handler returns.
+type PostUpload struct {
+	// Bucket is the bucket the upload was verified against.
+	Bucket string
+
+	// Key is the object key with any ${filename} variable substituted.
+	Key string
+
+	// Fields holds the form fields keyed by lower-cased name.
+	Fields map[string]string
+
+	// Filename is the file name supplied with the file part.
+	Filename string
+
+	// ContentType is the Content-Type of the file part.
+	ContentType string
+
+	// Policy is the decoded policy the upload was checked against.
+	Policy *PostPolicy
+
+	// File is the body of the file part.
+	File io.Reader
+}
+
+// PostPolicyVerifier verifies multipart/form-data POST uploads signed with
+// a SigV4 POST policy, as sent by browsers to S3-compatible gateways.
+// Thread safety follows Config.ThreadSafety as for Signer.
+type PostPolicyVerifier struct {
+	config       Config
+	keyDerivator KeyDeriver
+	names        postSigningFields
+}
+
+// postSigningFields are the form field names of the signing parameters of
+// a Profile, lower-cased, e.g. "x-amz-signature" or "x-goog-signature".
+type postSigningFields struct {
+	algorithm     string
+	credential    string
+	date          string
+	signature     string
+	securityToken string
+}
+
+func newPostSigningFields(p Profile) postSigningFields {
+	return postSigningFields{
+		algorithm:     strings.ToLower(p.AlgorithmKey()),
+		credential:    strings.ToLower(p.CredentialKey()),
+		date:          strings.ToLower(p.DateKey()),
+		signature:     strings.ToLower(p.SignatureKey()),
+		securityToken: strings.ToLower(p.SecurityTokenKey()),
+	}
+}
+
+// NewPostPolicyVerifier creates a verifier for uploads signed with the
+// credentials in config.
+func NewPostPolicyVerifier(config Config) (*PostPolicyVerifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &PostPolicyVerifier{
+		config:       config,
+		keyDerivator: newKeyDerivator(config),
+		names:        newPostSigningFields(config.Profile),
+	}, nil
+}
+
+// String formats the PostPolicyVerifier with the secrets of its Config redacted.
+func (v *PostPolicyVerifier) String() string {
+	return "PostPolicyVerifier{" + v.config.String() + "}"
+}
+
+// GoString formats the PostPolicyVerifier for %#v as String does.
+func (v *PostPolicyVerifier) GoString() string {
+	return "&signer." + v.String()
+}
+
+// Verify parses a POST upload for bucket, verifies the policy signature
+// and enforces the policy conditions and expiration at time now.
+// Form fields are read up to the file part, which S3 requires to be the
+// last field; any fields after it are ignored.
+// Reference: Amazon S3 API Reference, "Creating a POST Policy"
+func (v *PostPolicyVerifier) Verify(req *http.Request, bucket string, now time.Time) (upload *PostUpload, err error) {
+	if o := v.config.Observer; o != nil {
+		defer observeSince(req.Context(), o, OperationVerifyPOST, time.Now(), &err)
+	}
+	var ev *signingEvent
+	if ctx := req.Context(); debugEnabled(ctx, v.config.Logger) {
+		ev = &signingEvent{Method: req.Method, Host: GetHost(req)}
+		defer func() { ev.log(ctx, v.config.Logger, "verified POST upload", err) }()
+	}
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPostUpload, err)
+	}
+
+	fields := make(map[string]string)
+	var file *multipart.Part
+	remaining := int64(maxPostFormSize)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPostUpload, err)
+		}
+
+		name := strings.ToLower(part.FormName())
+		if name == "" {
+			continue
+		}
+		if name == postFieldFile {
+			file = part
+			break
+		}
+
+		value, err := io.ReadAll(io.LimitReader(part, remaining+1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPostUpload, err)
+		}
+		remaining -= int64(len(value))
+		if remaining < 0 {
+			return nil, fmt.Errorf("%w: form fields exceed %d bytes", ErrInvalidPostUpload, maxPostFormSize)
+		}
+		if _, ok := fields[name]; ok {
+			return nil, fmt.Errorf("%w: duplicate form field %q", ErrInvalidPostUpload, name)
+		}
+		fields[name] = string(value)
+	}
+	if file == nil {
+		return nil, fmt.Errorf("%w: no file field", ErrInvalidPostUpload)
+	}
+
+	if err := v.verifySignature(req.Context(), fields, ev); err != nil {
+		return nil, err
+	}
+
+	policy, err := DecodePostPolicy(fields[postFieldPolicy])
+	if err != nil {
+		return nil, err
+	}
+	if !now.Before(policy.Expiration) {
+		return nil, fmt.Errorf("%w at %s", ErrPostPolicyExpired, policy.Expiration.Format(time.RFC3339))
+	}
+
+	values := make(map[string]string, len(fields)+1)
+	for k, val := range fields {
+		values[k] = val
+	}
+	values[postFieldBucket] = bucket
+
+	minLength, maxLength := int64(0), int64(-1)
+	covered := make(map[string]bool)
+	for _, c := range policy.Conditions {
+		if c.Operator == PolicyConditionContentLengthRange {
+			minLength, maxLength = c.Min, c.Max
+			continue
+		}
+		covered[c.Field] = true
+		if err := c.check(values); err != nil {
+			return nil, err
+		}
+	}
+
+	for name := range fields {
+		switch name {
+		case postFieldPolicy, v.names.signature:
+			continue
+		}
+		if strings.HasPrefix(name, postIgnorePrefix) {
+			continue
+		}
+		if !covered[name] {
+			return nil, &PolicyError{Field: name, Reason: "is not covered by the policy"}
+		}
+	}
+
+	filename := file.FileName()
+	return &PostUpload{
+		Bucket:      bucket,
+		Key:         strings.ReplaceAll(fields[postFieldKey], "${filename}", filename),
+		Fields:      fields,
+		Filename:    filename,
+		ContentType: file.Header.Get("Content-Type"),
+		Policy:      policy,
+		File: &postFileReader{
+			r:   file,
+			min: minLength,
+			max: maxLength,
+		},
+	}, nil
+}
+
+// verifySignature checks the signature field, x-amz-signature for
+// AWSProfile, against the policy. The string to sign for a POST policy is
+// the base64 policy itself. If ev is not nil, the credential and key cache
+// use are recorded in it.
+func (v *PostPolicyVerifier) verifySignature(ctx context.Context, fields map[string]string, ev *signingEvent) error {
+	names := v.names
+	if ev != nil {
+		ev.Signature = fields[names.signature]
+	}
+	for _, name := range []string{
+		postFieldPolicy,
+		names.algorithm,
+		names.credential,
+		names.date,
+		names.signature,
+	} {
+		if fields[name] == "" {
+			return fmt.Errorf("%w: form field %q is required", ErrInvalidPostUpload, name)
+		}
+	}
+
+	if fields[names.algorithm] != v.config.Profile.Algorithm {
+		return fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, fields[names.algorithm])
+	}
+
+	date, err := time.Parse(TimeFormat, fields[names.date])
+	if err != nil {
+		return fmt.Errorf("%w: form field %q: %w", ErrInvalidSigningTime, names.date, err)
+	}
+	signingTime := NewSigningTime(date)
+
+	cred, err := parseCredential(fields[names.credential])
+	if err != nil {
+		return err
+	}
+	if ev != nil {
+		ev.AccessKeyID = cred.AccessKeyID
+		ev.Scope = strings.Join([]string{cred.Date, cred.Region, cred.Service, cred.Terminator}, "/")
+	}
+	if err := cred.check(v.config, signingTime); err != nil {
+		return err
+	}
+	if v.config.SessionToken != "" && fields[names.securityToken] != v.config.SessionToken {
+		return ErrSecurityTokenMismatch
+	}
+
+	key, cached, err := deriveSigningKey(
+		ctx,
+		v.config.Observer,
+		v.keyDerivator,
+		v.config.AccessKeyID,
+		v.config.SecretAccessKey,
+		v.config.Service,
+		v.config.Region,
+		signingTime,
+	)
+	if err != nil {
+		return err
+	}
+	if ev != nil {
+		ev.CachedKey = cached
+	}
+
+	expected, _ := hex.DecodeString(BuildSignature(key, fields[postFieldPolicy]))
+	actual, err := hex.DecodeString(fields[names.signature])
+	if err != nil || !hmac.Equal(expected, actual) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// DecodePostPolicy decodes a base64-encoded POST policy document.
+func DecodePostPolicy(encoded string) (*PostPolicy, error) {
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%w encoding: %w", ErrInvalidPostPolicy, err)
+	}
+
+	var doc struct {
+		Expiration string            `json:"expiration"`
+		Conditions []json.RawMessage `json:"conditions"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPostPolicy, err)
+	}
+	if doc.Expiration == "" {
+		return nil, fmt.Errorf("%w: expiration is required", ErrInvalidPostPolicy)
+	}
+
+	policy := &PostPolicy{}
+	policy.Expiration, err = time.Parse(time.RFC3339, doc.Expiration)
+	if err != nil {
+		return nil, fmt.Errorf("%w expiration: %w", ErrInvalidPostPolicy, err)
+	}
+
+	for _, raw := range doc.Conditions {
+		c, err := decodePostPolicyCondition(raw)
+		if err != nil {
+			return nil, err
+		}
+		policy.Conditions = append(policy.Conditions, c)
+	}
+	return policy, nil
+}
+
+// decodePostPolicyCondition decodes either form of a policy condition:
+// {"field": "value"} or [operator, "$field", value].
+func decodePostPolicyCondition(raw json.RawMessage) (PostPolicyCondition, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return PostPolicyCondition{}, fmt.Errorf("%w condition: %w", ErrInvalidPostPolicy, err)
+	}
+
+	switch c := v.(type) {
+	case map[string]interface{}:
+		if len(c) != 1 {
+			return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+		}
+		for field, value := range c {
+			s, ok := value.(string)
+			if !ok {
+				return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+			}
+			return PostPolicyCondition{
+				Operator: PolicyConditionEq,
+				Field:    strings.ToLower(strings.TrimPrefix(field, "$")),
+				Value:    s,
+			}, nil
+		}
+
+	case []interface{}:
+		if len(c) != 3 {
+			return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+		}
+		op, ok := c[0].(string)
+		if !ok {
+			return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+		}
+		op = strings.ToLower(op)
+
+		if op == PolicyConditionContentLengthRange {
+			minLength, err1 := policyInt(c[1])
+			maxLength, err2 := policyInt(c[2])
+			if err1 != nil || err2 != nil || minLength < 0 || maxLength < minLength {
+				return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+			}
+			return PostPolicyCondition{
+				Operator: op,
+				Min:      minLength,
+				Max:      maxLength,
+			}, nil
+		}
+
+		field, ok1 := c[1].(string)
+		value, ok2 := c[2].(string)
+		if !ok1 || !ok2 || !strings.HasPrefix(field, "$") {
+			return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+		}
+		if op != PolicyConditionEq && op != PolicyConditionStartsWith {
+			return PostPolicyCondition{}, fmt.Errorf("%w: unsupported operator %q", ErrInvalidPostPolicy, op)
+		}
+		return PostPolicyCondition{
+			Operator: op,
+			Field:    strings.ToLower(field[1:]),
+			Value:    value,
+		}, nil
+	}
+
+	return PostPolicyCondition{}, fmt.Errorf("%w condition %s", ErrInvalidPostPolicy, raw)
+}
+
+// policyInt accepts content-length-range bounds given as numbers or strings.
+func policyInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case json.Number:
+		return n.Int64()
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	}
+	return 0, fmt.Errorf("%w: %v is not an integer", ErrInvalidPostPolicy, v)
+}
+
+// check tests an eq or starts-with condition against the form values.
+func (c PostPolicyCondition) check(values map[string]string) error {
+	value := values[c.Field]
+	switch c.Operator {
+	case PolicyConditionEq:
+		if value != c.Value {
+			return &PolicyError{Field: c.Field, Reason: "does not match policy"}
+		}
+	case PolicyConditionStartsWith:
+		if !strings.HasPrefix(value, c.Value) {
+			return &PolicyError{Field: c.Field, Reason: fmt.Sprintf("does not start with %q", c.Value)}
+		}
+	}
+	return nil
+}
+
+// postFileReader enforces a content-length-range condition while the file
+// part is streamed. A negative max disables the bounds check.
+type postFileReader struct {
+	r   io.Reader
+	n   int64
+	min int64
+	max int64
+}
+
+// Read reads from the file part, failing as soon as the size exceeds the
+// policy maximum or, at end of file, if it is below the policy minimum.
+func (f *postFileReader) Read(p []byte) (int, error) {
+	n, err := f.r.Read(p)
+	f.n += int64(n)
+	if f.max >= 0 && f.n > f.max {
+		return n, &PolicyError{Field: postFieldFile, Reason: fmt.Sprintf("exceeds policy maximum of %d bytes", f.max)}
+	}
+	if err == io.EOF && f.max >= 0 && f.n < f.min {
+		return n, &PolicyError{Field: postFieldFile, Reason: fmt.Sprintf("%d is below policy minimum of %d bytes", f.n, f.min)}
+	}
+	return n, err
+}
@@ -0,0 +1,287 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+var postPolicyTime = time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC)
+
+// buildPostUpload builds a signed multipart POST upload for testConfig.
+// Fields are written in order, followed by the file part.
+func buildPostUpload(t *testing.T, policyJSON string, fields [][2]string, file string) *http.Request {
+	t.Helper()
+	return buildProfilePostUpload(t, AWSProfile, policyJSON, fields, file)
+}
+
+// buildProfilePostUpload builds a POST upload for testConfig signed under
+// profile, with the profile's signing field names.
+func buildProfilePostUpload(t *testing.T, profile Profile, policyJSON string, fields [][2]string, file string) *http.Request {
+	t.Helper()
+
+	st := NewSigningTime(postPolicyTime)
+	policy := base64.StdEncoding.EncodeToString([]byte(policyJSON))
+	key := profile.DeriveKey(testConfig.SecretAccessKey, testConfig.Service, testConfig.Region, st)
+
+	all := append([][2]string{
+		{"policy", policy},
+		{strings.ToLower(profile.AlgorithmKey()), profile.Algorithm},
+		{strings.ToLower(profile.CredentialKey()), testConfig.AccessKeyID + "/" + profile.CredentialScope(st, testConfig.Region, testConfig.Service)},
+		{strings.ToLower(profile.DateKey()), st.TimeFormat()},
+		{strings.ToLower(profile.SignatureKey()), BuildSignature(key, policy)},
+	}, fields...)
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, f := range all {
+		if err := w.WriteField(f[0], f[1]); err != nil {
+			t.Fatalf("failed to write field: %v", err)
+		}
+	}
+	fw, err := w.CreateFormFile("file", "report.txt")
+	if err != nil {
+		t.Fatalf("failed to create file part: %v", err)
+	}
+	fw.Write([]byte(file))
+	w.Close()
+
+	req, _ := http.NewRequest("POST", "https://bucket.example.com/", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+const testPostPolicy = `{
+	"expiration": "2023-12-01T13:00:00.000Z",
+	"conditions": [
+		{"bucket": "uploads"},
+		["starts-with", "$key", "user/"],
+		{"acl": "private"},
+		{"x-amz-algorithm": "AWS4-HMAC-SHA256"},
+		{"x-amz-credential": "AKID/20231201/us-east-1/s3/aws4_request"},
+		{"x-amz-date": "20231201T120000Z"},
+		["content-length-range", 1, 16]
+	]
+}`
+
+func TestPostPolicyVerify(t *testing.T) {
+	verifier, err := NewPostPolicyVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	req := buildPostUpload(t, testPostPolicy, [][2]string{
+		{"key", "user/${filename}"},
+		{"acl", "private"},
+		{"x-ignore-tracking", "1"},
+	}, "hello")
+
+	upload, err := verifier.Verify(req, "uploads", postPolicyTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if upload.Key != "user/report.txt" {
+		t.Errorf("expected key user/report.txt, got %s", upload.Key)
+	}
+
+	data, err := io.ReadAll(upload.File)
+	if err != nil {
+		t.Fatalf("expected no error reading file, got %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("expected file content hello, got %q", data)
+	}
+}
+
+func TestPostPolicyVerifyGCS(t *testing.T) {
+	config := testConfig
+	config.Profile = GCSProfile
+	verifier, _ := NewPostPolicyVerifier(config)
+
+	policy := `{
+		"expiration": "2023-12-01T13:00:00.000Z",
+		"conditions": [
+			{"bucket": "uploads"},
+			["starts-with", "$key", "user/"],
+			{"x-goog-algorithm": "GOOG4-HMAC-SHA256"},
+			{"x-goog-credential": "AKID/20231201/us-east-1/s3/goog4_request"},
+			{"x-goog-date": "20231201T120000Z"}
+		]
+	}`
+	req := buildProfilePostUpload(t, GCSProfile, policy, [][2]string{{"key", "user/a"}}, "hello")
+	if _, err := verifier.Verify(req, "uploads", postPolicyTime); err != nil {
+		t.Fatalf("expected GCS upload to verify, got %v", err)
+	}
+
+	// An AWS-signed upload is not accepted under the GCS profile.
+	req = buildPostUpload(t, testPostPolicy, [][2]string{{"key", "user/a"}, {"acl", "private"}}, "hello")
+	if _, err := verifier.Verify(req, "uploads", postPolicyTime); !errors.Is(err, ErrInvalidPostUpload) {
+		t.Errorf("expected ErrInvalidPostUpload for AWS fields, got %v", err)
+	}
+}
+
+func TestPostPolicyVerifyFailures(t *testing.T) {
+	verifier, err := NewPostPolicyVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		bucket string
+		fields [][2]string
+		now    time.Time
+	}{
+		{
+			name:   "wrong bucket",
+			bucket: "other",
+			fields: [][2]string{{"key", "user/a"}, {"acl", "private"}},
+			now:    postPolicyTime,
+		},
+		{
+			name:   "key prefix",
+			bucket: "uploads",
+			fields: [][2]string{{"key", "admin/a"}, {"acl", "private"}},
+			now:    postPolicyTime,
+		},
+		{
+			name:   "exact match",
+			bucket: "uploads",
+			fields: [][2]string{{"key", "user/a"}, {"acl", "public-read"}},
+			now:    postPolicyTime,
+		},
+		{
+			name:   "uncovered field",
+			bucket: "uploads",
+			fields: [][2]string{{"key", "user/a"}, {"acl", "private"}, {"x-amz-meta-owner", "me"}},
+			now:    postPolicyTime,
+		},
+		{
+			name:   "expired",
+			bucket: "uploads",
+			fields: [][2]string{{"key", "user/a"}, {"acl", "private"}},
+			now:    postPolicyTime.Add(2 * time.Hour),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := buildPostUpload(t, testPostPolicy, tt.fields, "hello")
+			if _, err := verifier.Verify(req, tt.bucket, tt.now); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestPostPolicyVerifyBadSignature(t *testing.T) {
+	// The upload is signed with testConfig but verified with another secret.
+	other := testConfig
+	other.SecretAccessKey = "OTHER"
+	verifier, err := NewPostPolicyVerifier(other)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	req := buildPostUpload(t, testPostPolicy, nil, "hello")
+
+	_, err = verifier.Verify(req, "uploads", postPolicyTime)
+	if err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("expected signature error, got %v", err)
+	}
+}
+
+func TestPostPolicyVerifySessionToken(t *testing.T) {
+	config := testConfig
+	config.SessionToken = "TOKEN"
+	verifier, err := NewPostPolicyVerifier(config)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	policy := `{
+		"expiration": "2023-12-01T13:00:00.000Z",
+		"conditions": [
+			{"bucket": "uploads"},
+			["starts-with", "$key", "user/"],
+			["starts-with", "$x-amz-security-token", ""],
+			{"x-amz-algorithm": "AWS4-HMAC-SHA256"},
+			{"x-amz-credential": "AKID/20231201/us-east-1/s3/aws4_request"},
+			{"x-amz-date": "20231201T120000Z"}
+		]
+	}`
+	tests := []struct {
+		name   string
+		fields [][2]string
+		err    error
+	}{
+		{"matching token", [][2]string{{"key", "user/a"}, {"x-amz-security-token", "TOKEN"}}, nil},
+		{"missing token", [][2]string{{"key", "user/a"}}, ErrSecurityTokenMismatch},
+		{"other token", [][2]string{{"key", "user/a"}, {"x-amz-security-token", "OTHER"}}, ErrSecurityTokenMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := buildPostUpload(t, policy, tt.fields, "hello")
+			if _, err := verifier.Verify(req, "uploads", postPolicyTime); !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestPostPolicyContentLengthRange(t *testing.T) {
+	verifier, err := NewPostPolicyVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	for _, content := range []string{"", "this file is far too large"} {
+		req := buildPostUpload(t, testPostPolicy, [][2]string{
+			{"key", "user/a"},
+			{"acl", "private"},
+		}, content)
+
+		upload, err := verifier.Verify(req, "uploads", postPolicyTime)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if _, err := io.ReadAll(upload.File); err == nil {
+			t.Errorf("expected size error for %d byte file", len(content))
+		}
+	}
+}
+
+func TestDecodePostPolicy(t *testing.T) {
+	policy, err := DecodePostPolicy(base64.StdEncoding.EncodeToString([]byte(testPostPolicy)))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if !policy.Expiration.Equal(time.Date(2023, 12, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected expiration %s", policy.Expiration)
+	}
+	if len(policy.Conditions) != 7 {
+		t.Fatalf("expected 7 conditions, got %d", len(policy.Conditions))
+	}
+
+	c := policy.Conditions[1]
+	if c.Operator != PolicyConditionStartsWith || c.Field != "key" || c.Value != "user/" {
+		t.Errorf("unexpected condition %+v", c)
+	}
+
+	c = policy.Conditions[6]
+	if c.Operator != PolicyConditionContentLengthRange || c.Min != 1 || c.Max != 16 {
+		t.Errorf("unexpected condition %+v", c)
+	}
+
+	if _, err := DecodePostPolicy("not base64!"); err == nil {
+		t.Error("expected error for invalid encoding")
+	}
+}
@@ -0,0 +1,136 @@
+package signer
+
+import "strings"
+
+// Profile names the parts of a SigV4-style signing scheme that differ
+// between providers: the algorithm identifier, the key derivation prefix,
+// the credential scope terminator and the prefix of the header and query
+// parameter names. The zero Profile is AWSProfile.
+type Profile struct {
+	// Algorithm identifies the scheme, e.g. "AWS4-HMAC-SHA256".
+	Algorithm string
+
+	// KeyPrefix is prepended to the secret when deriving keys, e.g. "AWS4".
+	KeyPrefix string
+
+	// Terminator ends the credential scope, e.g. "aws4_request".
+	Terminator string
+
+	// ParamPrefix prefixes header and query parameter names, e.g. "X-Amz-".
+	ParamPrefix string
+}
+
+// AWSProfile is AWS Signature Version 4, also used by R2 and other S3
+// compatible services.
+var AWSProfile = Profile{
+	Algorithm:   SigningAlgorithm,
+	KeyPrefix:   "AWS4",
+	Terminator:  "aws4_request",
+	ParamPrefix: "X-Amz-",
+}
+
+// GCSProfile is the GOOG4-HMAC-SHA256 scheme accepted by the Google Cloud
+// Storage XML API with HMAC keys.
+// Reference: Google Cloud Storage, "V4 signing process with your own program"
+var GCSProfile = Profile{
+	Algorithm:   "GOOG4-HMAC-SHA256",
+	KeyPrefix:   "GOOG4",
+	Terminator:  "goog4_request",
+	ParamPrefix: "X-Goog-",
+}
+
+// orDefault returns AWSProfile for the zero Profile.
+func (p Profile) orDefault() Profile {
+	if p == (Profile{}) {
+		return AWSProfile
+	}
+	return p
+}
+
+// key returns the parameter name with the profile's prefix. The AWS
+// names are constants, so the default profile does not allocate.
+func (p Profile) key(awsKey, name string) string {
+	if p.ParamPrefix == AWSProfile.ParamPrefix {
+		return awsKey
+	}
+	return p.ParamPrefix + name
+}
+
+// AlgorithmKey returns the query parameter key for the algorithm.
+func (p Profile) AlgorithmKey() string { return p.key(AmzAlgorithmKey, "Algorithm") }
+
+// DateKey returns the header/query key for the request timestamp.
+func (p Profile) DateKey() string { return p.key(AmzDateKey, "Date") }
+
+// CredentialKey returns the query parameter key for credentials.
+func (p Profile) CredentialKey() string { return p.key(AmzCredentialKey, "Credential") }
+
+// SignedHeadersKey returns the query parameter key for signed headers.
+func (p Profile) SignedHeadersKey() string { return p.key(AmzSignedHeadersKey, "SignedHeaders") }
+
+// SignatureKey returns the query parameter key for the signature.
+func (p Profile) SignatureKey() string { return p.key(AmzSignatureKey, "Signature") }
+
+// ExpiresKey returns the query parameter key for presigned URL expiry.
+func (p Profile) ExpiresKey() string { return p.key(AmzExpiresKey, "Expires") }
+
+// SecurityTokenKey returns the header/query key for the session token.
+func (p Profile) SecurityTokenKey() string { return p.key(AmzSecurityTokenKey, "Security-Token") }
+
+// ContentSHAKey returns the header key for the request body SHA256 hash.
+func (p Profile) ContentSHAKey() string { return p.key(ContentSHAKey, "Content-Sha256") }
+
+// CredentialScope builds the credential scope.
+// Format: date/region/service/terminator
+func (p Profile) CredentialScope(t SigningTime, region, service string) string {
+	return strings.Join([]string{
+		t.ShortTimeFormat(),
+		region,
+		service,
+		p.Terminator,
+	}, "/")
+}
+
+// DeriveKey derives the signing key as DeriveKey does, with the profile's
+// key prefix and terminator.
+func (p Profile) DeriveKey(secret, service, region string, t SigningTime) []byte {
+	// kDate = HMAC-SHA256(prefix + secret, date)
+	kDate := HMACSHA256([]byte(p.KeyPrefix+secret), []byte(t.ShortTimeFormat()))
+
+	// kRegion = HMAC-SHA256(kDate, region)
+	kRegion := HMACSHA256(kDate, []byte(region))
+
+	// kService = HMAC-SHA256(kRegion, service)
+	kService := HMACSHA256(kRegion, []byte(service))
+
+	// kSigning = HMAC-SHA256(kService, terminator)
+	return HMACSHA256(kService, []byte(p.Terminator))
+}
+
+// AuthorizationHeader builds the Authorization header value.
+// Format: ALGORITHM Credential=..., SignedHeaders=..., Signature=...
+func (p Profile) AuthorizationHeader(credentialStr, signedHeadersStr, signature string) string {
+	const credential = "Credential="
+	const signedHeaders = "SignedHeaders="
+	const signatureKey = "Signature="
+	const commaSpace = ", "
+
+	var parts strings.Builder
+	parts.Grow(
+		len(p.Algorithm) + 1 +
+			len(credential) + len(credentialStr) + 2 +
+			len(signedHeaders) + len(signedHeadersStr) + 2 +
+			len(signatureKey) + len(signature),
+	)
+	parts.WriteString(p.Algorithm)
+	parts.WriteRune(' ')
+	parts.WriteString(credential)
+	parts.WriteString(credentialStr)
+	parts.WriteString(commaSpace)
+	parts.WriteString(signedHeaders)
+	parts.WriteString(signedHeadersStr)
+	parts.WriteString(commaSpace)
+	parts.WriteString(signatureKey)
+	parts.WriteString(signature)
+	return parts.String()
+}
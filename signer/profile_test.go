@@ -0,0 +1,131 @@
+package signer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileDefaults(t *testing.T) {
+	config := testConfig
+	if err := config.Validate(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if config.Profile != AWSProfile {
+		t.Errorf("expected AWSProfile by default, got %+v", config.Profile)
+	}
+
+	keys := map[string]string{
+		AWSProfile.AlgorithmKey():     AmzAlgorithmKey,
+		AWSProfile.DateKey():          AmzDateKey,
+		AWSProfile.CredentialKey():    AmzCredentialKey,
+		AWSProfile.SignedHeadersKey(): AmzSignedHeadersKey,
+		AWSProfile.SignatureKey():     AmzSignatureKey,
+		AWSProfile.ExpiresKey():       AmzExpiresKey,
+		AWSProfile.SecurityTokenKey(): AmzSecurityTokenKey,
+		AWSProfile.ContentSHAKey():    ContentSHAKey,
+	}
+	for got, expected := range keys {
+		if got != expected {
+			t.Errorf("expected %s, got %s", expected, got)
+		}
+	}
+}
+
+func TestGCSProfileDeriveKey(t *testing.T) {
+	st := NewSigningTime(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	kDate := HMACSHA256([]byte("GOOG4SECRET"), []byte("20240301"))
+	kRegion := HMACSHA256(kDate, []byte("auto"))
+	kService := HMACSHA256(kRegion, []byte("storage"))
+	expected := HMACSHA256(kService, []byte("goog4_request"))
+
+	if got := GCSProfile.DeriveKey("SECRET", "storage", "auto", st); string(got) != string(expected) {
+		t.Error("unexpected GCS signing key")
+	}
+	// The caching derivator of a Signer uses the configured profile.
+	config := Config{Region: "auto", AccessKeyID: "AKID", SecretAccessKey: "SECRET", Service: "storage", Profile: GCSProfile}
+	if got := newKeyDerivator(config).DeriveKey("AKID", "SECRET", "storage", "auto", st); string(got) != string(expected) {
+		t.Error("expected key derivator to use GCSProfile")
+	}
+	if string(AWSProfile.DeriveKey("SECRET", "storage", "auto", st)) != string(DeriveKey("SECRET", "storage", "auto", st)) {
+		t.Error("expected AWSProfile to match DeriveKey")
+	}
+}
+
+func TestSignHTTPGCSProfile(t *testing.T) {
+	config := Config{
+		Region:          "auto",
+		AccessKeyID:     "GOOGTS7C7FUP3AIRVJTE2BCD",
+		SecretAccessKey: "SECRET",
+		Service:         "storage",
+		Profile:         GCSProfile,
+	}
+	signer, _ := NewSigner(config)
+	verifier, _ := NewVerifier(config)
+	signingTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	req, payloadHash := buildTestRequest("GET", "https://storage.googleapis.com/bucket/object", "")
+	req.Header.Set(GCSProfile.ContentSHAKey(), payloadHash)
+	if err := signer.SignHTTP(req, payloadHash, signingTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	auth := req.Header.Get(AuthorizationHeader)
+	if !strings.HasPrefix(auth, "GOOG4-HMAC-SHA256 Credential=GOOGTS7C7FUP3AIRVJTE2BCD/20240301/auto/storage/goog4_request, ") {
+		t.Errorf("unexpected authorization header %s", auth)
+	}
+	if req.Header.Get("X-Goog-Date") != "20240301T120000Z" || req.Header.Get(AmzDateKey) != "" {
+		t.Errorf("expected X-Goog-Date only, got %v", req.Header)
+	}
+	if err := verifier.VerifyHTTP(serverSide(t, req), payloadHash, signingTime); err != nil {
+		t.Errorf("expected request to verify, got %v", err)
+	}
+
+	// An AWS verifier with the same credentials rejects the request.
+	config.Profile = Profile{}
+	awsVerifier, _ := NewVerifier(config)
+	if err := awsVerifier.VerifyHTTP(serverSide(t, req), payloadHash, signingTime); err == nil {
+		t.Error("expected AWS verifier to reject a GOOG4 signature")
+	}
+}
+
+func TestPresignHTTPGCSProfile(t *testing.T) {
+	config := Config{
+		Region:          "auto",
+		AccessKeyID:     "GOOGKEY",
+		SecretAccessKey: "SECRET",
+		Service:         "storage",
+		Profile:         GCSProfile,
+	}
+	signer, _ := NewSigner(config)
+	verifier, _ := NewVerifier(config)
+	signingTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	req, _ := http.NewRequest("GET", "https://storage.googleapis.com/bucket/object?X-Goog-Expires=600", nil)
+	signedURL, _, err := signer.PresignHTTP(req, UnsignedPayload, signingTime)
+	if err != nil {
+		t.Fatalf("failed to presign: %v", err)
+	}
+
+	u, _ := url.Parse(signedURL)
+	query := u.Query()
+	for _, k := range []string{"X-Goog-Algorithm", "X-Goog-Credential", "X-Goog-Date", "X-Goog-SignedHeaders", "X-Goog-Signature"} {
+		if query.Get(k) == "" {
+			t.Errorf("expected %s in %s", k, signedURL)
+		}
+	}
+	if query.Has(AmzSignatureKey) {
+		t.Errorf("unexpected %s in %s", AmzSignatureKey, signedURL)
+	}
+
+	received := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	if !IsPresigned(received) {
+		t.Error("expected GCS URL to be recognized as presigned")
+	}
+	if err := verifier.VerifyPresignedHTTP(received, UnsignedPayload, signingTime.Add(time.Minute)); err != nil {
+		t.Errorf("expected URL to verify, got %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildRDSAuthToken(t *testing.T) {
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+	}
+	signingTime := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	token, err := BuildRDSAuthToken("prod-instance.us-east-1.rds.amazonaws.com:3306", "mysqlUser", config, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	prefix := "prod-instance.us-east-1.rds.amazonaws.com:3306?Action=connect&DBUser=mysqlUser&X-Amz-Algorithm=AWS4-HMAC-SHA256&" +
+		"X-Amz-Credential=AKID%2F20160101%2Fus-east-1%2Frds-db%2Faws4_request&X-Amz-Date=20160101T000000Z&X-Amz-Expires=900&" +
+		"X-Amz-SignedHeaders=host&X-Amz-Signature="
+	if !strings.HasPrefix(token, prefix) {
+		t.Errorf("unexpected token %s", token)
+	}
+
+	// The database verifies the token as a presigned rds-db request.
+	config.Service = RDSService
+	verifier, _ := NewVerifier(config)
+	u, _ := url.Parse("https://" + token)
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime.Add(14*time.Minute)); err != nil {
+		t.Errorf("expected token to verify, got %v", err)
+	}
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime.Add(16*time.Minute)); err == nil {
+		t.Error("expected token to expire after 15 minutes")
+	}
+}
+
+func TestBuildRDSAuthTokenDefaultPort(t *testing.T) {
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+	}
+	signingTime := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	token, err := BuildRDSAuthToken("prod-instance.us-east-1.rds.amazonaws.com:443", "pgUser", config, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(token, "prod-instance.us-east-1.rds.amazonaws.com:443?Action=connect&") {
+		t.Errorf("unexpected token %s", token)
+	}
+
+	// The host is signed with the port the token carries.
+	config.Service = RDSService
+	verifier, _ := NewVerifier(config)
+	u, _ := url.Parse("https://" + token)
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+		t.Errorf("expected token to verify, got %v", err)
+	}
+}
+
+func TestBuildRDSAuthTokenErrors(t *testing.T) {
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+	}
+
+	tests := []struct {
+		name     string
+		endpoint string
+		user     string
+		config   Config
+		field    string
+	}{
+		{name: "missing endpoint", user: "u", config: config, field: "Endpoint"},
+		{name: "missing port", endpoint: "db.example.com", user: "u", config: config, field: "Endpoint"},
+		{name: "missing user", endpoint: "db.example.com:5432", config: config, field: "DBUser"},
+		{name: "missing region", endpoint: "db.example.com:5432", user: "u", config: Config{AccessKeyID: "AKID", SecretAccessKey: "SECRET"}, field: "Region"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildRDSAuthToken(tt.endpoint, tt.user, tt.config, time.Now())
+			var configErr *ConfigError
+			if !errors.As(err, &configErr) || configErr.Field != tt.field {
+				t.Errorf("expected *ConfigError for %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
@@ -75,7 +75,7 @@ func IsDefaultPort(scheme, port string) bool {
 		return true
 	}
 	lowerScheme := strings.ToLower(scheme)
-	return (lowerScheme == "http" && port == "80") ||
-		(lowerScheme == "https" && port == "443")
+	return ((lowerScheme == "http" || lowerScheme == "ws") && port == "80") ||
+		((lowerScheme == "https" || lowerScheme == "wss") && port == "443")
 }
 
@@ -0,0 +1,167 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// S3Endpoint builds requests for objects on an S3-compatible endpoint
+// such as a Cloudflare R2 account endpoint, MinIO or AWS S3.
+// Reference: Amazon S3 User Guide, "Virtual hosting of buckets"
+type S3Endpoint struct {
+	url *url.URL
+
+	// ForcePathStyle always addresses buckets in the URL path
+	// (https://host/bucket/key) rather than in the host name
+	// (https://bucket.host/key). MinIO deployments usually need this.
+	ForcePathStyle bool
+}
+
+// NewS3Endpoint parses an endpoint URL such as
+// "https://<account>.r2.cloudflarestorage.com". Any path on the endpoint
+// is kept as a prefix for every request.
+func NewS3Endpoint(endpoint string) (*S3Endpoint, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, &ConfigError{Field: "Endpoint", Reason: fmt.Sprintf("is invalid: %v", err)}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, &ConfigError{Field: "Endpoint", Reason: fmt.Sprintf("scheme %q is unsupported", u.Scheme)}
+	}
+	if u.Host == "" {
+		return nil, &ConfigError{Field: "Endpoint", Reason: "host is required"}
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return nil, &ConfigError{Field: "Endpoint", Reason: "query and fragment are not allowed"}
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return &S3Endpoint{url: u}, nil
+}
+
+// UsePathStyle reports whether bucket is addressed path-style.
+// Path-style is forced for IP address and localhost endpoints, for bucket
+// names that are not valid DNS labels, and for dotted bucket names over
+// TLS, where the wildcard certificate would not match the host name.
+func (e *S3Endpoint) UsePathStyle(bucket string) bool {
+	if e.ForcePathStyle {
+		return true
+	}
+	host := StripPort(e.url.Host)
+	if net.ParseIP(host) != nil || host == "localhost" {
+		return true
+	}
+	if !isDNSCompatibleBucket(bucket) {
+		return true
+	}
+	return e.url.Scheme == "https" && strings.Contains(bucket, ".")
+}
+
+// ObjectURL returns the URL of key in bucket with query appended.
+// An empty key addresses the bucket itself, and an empty bucket the
+// service. Keys are escaped with EscapeS3Key so that the request path is
+// used unchanged as the canonical URI by GetURIPath.
+func (e *S3Endpoint) ObjectURL(bucket, key string, query url.Values) (*url.URL, error) {
+	if bucket == "" && key != "" {
+		return nil, &ConfigError{Field: "Bucket", Reason: fmt.Sprintf("is required for key %q", key)}
+	}
+
+	u := *e.url
+	path, rawPath := e.url.Path, e.url.EscapedPath()
+	if rawPath == "/" {
+		path, rawPath = "", ""
+	}
+
+	if bucket != "" {
+		if e.UsePathStyle(bucket) {
+			path += "/" + bucket
+			rawPath += "/" + EscapeS3Key(bucket)
+		} else {
+			u.Host = bucket + "." + u.Host
+		}
+	}
+	if key != "" {
+		path += "/" + key
+		rawPath += "/" + EscapeS3Key(key)
+	}
+	if path == "" {
+		path, rawPath = "/", "/"
+	}
+
+	u.Path = path
+	if rawPath != path {
+		u.RawPath = rawPath
+	}
+	if len(query) > 0 {
+		u.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
+	}
+	return &u, nil
+}
+
+// NewRequest creates a request for key in bucket, ready to be passed to
+// Signer.SignHTTP or Signer.PresignHTTP.
+func (e *S3Endpoint) NewRequest(ctx context.Context, method, bucket, key string, query url.Values, body io.Reader) (*http.Request, error) {
+	u, err := e.ObjectURL(bucket, key, query)
+	if err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// EscapeS3Key escapes an object key for use in a request path.
+// Every byte other than the RFC 3986 unreserved characters and "/" is
+// percent-encoded, matching the S3 canonical URI encoding.
+// Reference: AWS SigV4 spec, "Create a canonical request"
+func EscapeS3Key(key string) string {
+	const hex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if isUnreserved(c) || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		b.WriteByte('%')
+		b.WriteByte(hex[c>>4])
+		b.WriteByte(hex[c&0x0f])
+	}
+	return b.String()
+}
+
+// isUnreserved reports whether c is an RFC 3986 unreserved character.
+func isUnreserved(c byte) bool {
+	return 'A' <= c && c <= 'Z' ||
+		'a' <= c && c <= 'z' ||
+		'0' <= c && c <= '9' ||
+		c == '-' || c == '_' || c == '.' || c == '~'
+}
+
+// isDNSCompatibleBucket reports whether bucket can be used as a host name
+// label for virtual-hosted addressing.
+// Reference: Amazon S3 User Guide, "Bucket naming rules"
+func isDNSCompatibleBucket(bucket string) bool {
+	if len(bucket) < 3 || len(bucket) > 63 {
+		return false
+	}
+	if net.ParseIP(bucket) != nil || strings.Contains(bucket, "..") {
+		return false
+	}
+	for i := 0; i < len(bucket); i++ {
+		c := bucket[i]
+		alnum := 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+		if (i == 0 || i == len(bucket)-1) && !alnum {
+			return false
+		}
+		if !alnum && c != '-' && c != '.' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,172 @@
+package signer
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestS3EndpointObjectURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoint  string
+		pathStyle bool
+		bucket    string
+		key       string
+		expected  string
+	}{
+		{
+			name:     "virtual-hosted",
+			endpoint: "https://account.r2.cloudflarestorage.com",
+			bucket:   "logs",
+			key:      "2023/12/01/segment.log",
+			expected: "https://logs.account.r2.cloudflarestorage.com/2023/12/01/segment.log",
+		},
+		{
+			name:      "forced path-style",
+			endpoint:  "http://minio:9000",
+			pathStyle: true,
+			bucket:    "logs",
+			key:       "a",
+			expected:  "http://minio:9000/logs/a",
+		},
+		{
+			name:     "dotted bucket over TLS",
+			endpoint: "https://s3.us-east-1.amazonaws.com",
+			bucket:   "logs.example.com",
+			key:      "a",
+			expected: "https://s3.us-east-1.amazonaws.com/logs.example.com/a",
+		},
+		{
+			name:     "dotted bucket over plain HTTP",
+			endpoint: "http://s3.local",
+			bucket:   "logs.example.com",
+			key:      "a",
+			expected: "http://logs.example.com.s3.local/a",
+		},
+		{
+			name:     "IP endpoint",
+			endpoint: "http://127.0.0.1:9000",
+			bucket:   "logs",
+			key:      "a",
+			expected: "http://127.0.0.1:9000/logs/a",
+		},
+		{
+			name:     "bucket only",
+			endpoint: "https://s3.amazonaws.com",
+			bucket:   "logs",
+			expected: "https://logs.s3.amazonaws.com/",
+		},
+		{
+			name:     "service",
+			endpoint: "https://s3.amazonaws.com/",
+			expected: "https://s3.amazonaws.com/",
+		},
+		{
+			name:     "endpoint path prefix",
+			endpoint: "http://gateway.local/s3/",
+			bucket:   "Logs_Bucket",
+			key:      "a",
+			expected: "http://gateway.local/s3/Logs_Bucket/a",
+		},
+		{
+			name:     "escaped key",
+			endpoint: "https://s3.amazonaws.com",
+			bucket:   "logs",
+			key:      "dir/a b+c=d~é",
+			expected: "https://logs.s3.amazonaws.com/dir/a%20b%2Bc%3Dd~%C3%A9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewS3Endpoint(tt.endpoint)
+			if err != nil {
+				t.Fatalf("failed to create endpoint: %v", err)
+			}
+			e.ForcePathStyle = tt.pathStyle
+
+			u, err := e.ObjectURL(tt.bucket, tt.key, nil)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if u.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, u.String())
+			}
+		})
+	}
+}
+
+func TestS3EndpointNewRequest(t *testing.T) {
+	e, err := NewS3Endpoint("https://account.r2.cloudflarestorage.com")
+	if err != nil {
+		t.Fatalf("failed to create endpoint: %v", err)
+	}
+
+	query := url.Values{}
+	query.Set("list-type", "2")
+	query.Set("prefix", "a b/")
+
+	req, err := e.NewRequest(context.Background(), "GET", "logs", "dir/a b?.txt", query, nil)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if got := GetURIPath(req.URL); got != "/dir/a%20b%3F.txt" {
+		t.Errorf("expected canonical URI /dir/a%%20b%%3F.txt, got %s", got)
+	}
+	if req.URL.RawQuery != "list-type=2&prefix=a%20b%2F" {
+		t.Errorf("unexpected query %s", req.URL.RawQuery)
+	}
+
+	signer, err := NewSigner(Config{
+		Region:          "auto",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+	})
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	if err := signer.SignHTTP(req, EmptyStringSHA256, time.Unix(0, 0)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(req.Header.Get(AuthorizationHeader), SigningAlgorithm) {
+		t.Error("Authorization header should be set")
+	}
+}
+
+func TestS3EndpointErrors(t *testing.T) {
+	for _, endpoint := range []string{"ftp://host", "https://", "https://host/?a=b"} {
+		var configErr *ConfigError
+		if _, err := NewS3Endpoint(endpoint); !errors.As(err, &configErr) || configErr.Field != "Endpoint" {
+			t.Errorf("expected *ConfigError for endpoint %q, got %v", endpoint, err)
+		}
+	}
+
+	e, _ := NewS3Endpoint("https://s3.amazonaws.com")
+	var configErr *ConfigError
+	if _, err := e.ObjectURL("", "key", nil); !errors.As(err, &configErr) || configErr.Field != "Bucket" {
+		t.Errorf("expected *ConfigError for key without bucket, got %v", err)
+	}
+}
+
+func TestEscapeS3Key(t *testing.T) {
+	tests := map[string]string{
+		"simple":          "simple",
+		"a/b/c":           "a/b/c",
+		"with space":      "with%20space",
+		"plus+equals=":    "plus%2Bequals%3D",
+		"-_.~":            "-_.~",
+		"percent%":        "percent%25",
+		"unicode/ünïcödé": "unicode/%C3%BCn%C3%AFc%C3%B6d%C3%A9",
+	}
+
+	for input, expected := range tests {
+		if got := EscapeS3Key(input); got != expected {
+			t.Errorf("EscapeS3Key(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+}
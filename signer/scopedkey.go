@@ -0,0 +1,190 @@
+package signer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ScopedKey is a signing key derived for one day, region and service. A
+// Signer holding the long-term secret exports it with Signer.ScopedKey,
+// and a less trusted component signs with it through NewScopedSigner,
+// without access to the secret or to any other scope.
+// Reference: AWS General Reference, "Signature Version 4 signing process",
+// deriving the signing key
+type ScopedKey struct {
+	AccessKeyID string
+
+	// Date is the day of the scope, formatted as YYYYMMDD.
+	Date string
+
+	Region  string
+	Service string
+
+	// Profile is the signing profile the key was derived with, whose
+	// key prefix and terminator it embeds (defaults to AWSProfile).
+	Profile Profile
+
+	// Key is the derived signing key.
+	Key []byte
+}
+
+// Scope returns the date/region/service scope of the key.
+func (k ScopedKey) Scope() string {
+	return strings.Join([]string{k.Date, k.Region, k.Service}, "/")
+}
+
+// String formats the ScopedKey with Key redacted.
+func (k ScopedKey) String() string {
+	key := ""
+	if len(k.Key) > 0 {
+		key = redacted
+	}
+	return fmt.Sprintf("ScopedKey{AccessKeyID: %s, Scope: %s, Key: %s}", k.AccessKeyID, k.Scope(), key)
+}
+
+// GoString formats the ScopedKey for %#v as String does.
+func (k ScopedKey) GoString() string {
+	return "signer." + k.String()
+}
+
+// ScopeError reports a request to sign outside the scope of a ScopedKey.
+type ScopeError struct {
+	// KeyScope is the scope of the key, as ScopedKey.Scope, followed by
+	// the profile terminator if the profiles differ.
+	KeyScope string
+
+	// Scope is the date/region/service scope of the request, followed by
+	// the profile terminator if the profiles differ.
+	Scope string
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("scope %s is outside the signing key scope %s", e.Scope, e.KeyScope)
+}
+
+func (e *ScopeError) category() ErrorCategory { return ErrorCategoryCredential }
+
+// ScopedKey exports the signing key of the Signer's region and service for
+// the day of signingTime, for delegation to NewScopedSigner.
+func (s *Signer) ScopedKey(signingTime time.Time) (ScopedKey, error) {
+	if err := s.config.checkExpiry(signingTime); err != nil {
+		return ScopedKey{}, err
+	}
+	st := NewSigningTime(signingTime)
+	key, _, err := deriveSigningKey(
+		context.Background(),
+		s.config.Observer,
+		s.keyDerivator,
+		s.config.AccessKeyID,
+		s.config.SecretAccessKey,
+		s.config.Service,
+		s.config.Region,
+		st,
+	)
+	if err != nil {
+		return ScopedKey{}, err
+	}
+	return ScopedKey{
+		AccessKeyID: s.config.AccessKeyID,
+		Date:        st.ShortTimeFormat(),
+		Region:      s.config.Region,
+		Service:     s.config.Service,
+		Profile:     s.config.Profile,
+		Key:         append([]byte(nil), key...),
+	}, nil
+}
+
+// NewScopedSigner creates a Signer that signs with key only. Signing at a
+// time outside the key's day fails with a *ScopeError, as does a
+// config.Profile other than the key's. The access key, region and service
+// of config default to those of key and must match them if set;
+// config.SecretAccessKey and config.KeyDeriver must be unset.
+func NewScopedSigner(config Config, key ScopedKey) (*Signer, error) {
+	if _, err := time.Parse(ShortTimeFormat, key.Date); err != nil {
+		return nil, &ConfigError{Field: "ScopedKey.Date", Reason: fmt.Sprintf("%q is invalid", key.Date)}
+	}
+	if len(key.Key) == 0 {
+		return nil, &ConfigError{Field: "ScopedKey.Key", Reason: "is required"}
+	}
+	if config.SecretAccessKey != "" {
+		return nil, &ConfigError{Field: "SecretAccessKey", Reason: "must not be set for a scoped signer"}
+	}
+	if config.KeyDeriver != nil {
+		return nil, &ConfigError{Field: "KeyDeriver", Reason: "must not be set for a scoped signer"}
+	}
+	for _, f := range []struct {
+		name     string
+		value    *string
+		keyValue string
+	}{
+		{"AccessKeyID", &config.AccessKeyID, key.AccessKeyID},
+		{"Region", &config.Region, key.Region},
+		{"Service", &config.Service, key.Service},
+	} {
+		if *f.value == "" {
+			*f.value = f.keyValue
+		} else if *f.value != f.keyValue {
+			return nil, &ConfigError{Field: f.name, Reason: fmt.Sprintf("%q does not match scoped key %q", *f.value, f.keyValue)}
+		}
+	}
+	if keyProfile, profile := key.Profile.orDefault(), config.Profile.orDefault(); keyProfile != profile {
+		return nil, &ScopeError{
+			KeyScope: key.Scope() + "/" + keyProfile.Terminator,
+			Scope:    key.Scope() + "/" + profile.Terminator,
+		}
+	}
+
+	key.Key = append([]byte(nil), key.Key...)
+	deriver := &scopedKeyDeriver{key: key}
+	config.KeyDeriver = deriver
+
+	s, err := NewSigner(config)
+	if err != nil {
+		return nil, err
+	}
+	s.keyDerivator = deriver
+	return s, nil
+}
+
+// scopedKeyDeriver is the KeyDeriver of a scoped Signer.
+type scopedKeyDeriver struct {
+	key    ScopedKey
+	closed atomic.Bool
+}
+
+// DeriveKey returns the scoped key, or nil outside its scope.
+func (d *scopedKeyDeriver) DeriveKey(accessKeyID, secretAccessKey, service, region string, signingTime SigningTime) []byte {
+	if d.check(accessKeyID, service, region, signingTime) != nil {
+		return nil
+	}
+	return d.key.Key
+}
+
+// check returns a *ScopeError if the key does not cover the scope, a
+// *CredentialError for another access key, or ErrClosed if it was closed.
+func (d *scopedKeyDeriver) check(accessKeyID, service, region string, signingTime SigningTime) error {
+	if d.closed.Load() {
+		return ErrClosed
+	}
+	if accessKeyID != d.key.AccessKeyID {
+		return &CredentialError{Field: "AccessKeyID", Value: accessKeyID, Expected: d.key.AccessKeyID}
+	}
+	date := signingTime.ShortTimeFormat()
+	if date != d.key.Date || region != d.key.Region || service != d.key.Service {
+		return &ScopeError{
+			KeyScope: d.key.Scope(),
+			Scope:    strings.Join([]string{date, region, service}, "/"),
+		}
+	}
+	return nil
+}
+
+// Close zeroes the scoped key, after which it signs nothing.
+func (d *scopedKeyDeriver) Close() error {
+	d.closed.Store(true)
+	clear(d.key.Key)
+	return nil
+}
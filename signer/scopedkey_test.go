@@ -0,0 +1,145 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestScopedSigner(t *testing.T) {
+	full, err := NewSigner(testConfig)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	key, err := full.ScopedKey(signingTime)
+	if err != nil {
+		t.Fatalf("ScopedKey failed: %v", err)
+	}
+	if got, want := key.Scope(), "20240115/us-east-1/s3"; got != want {
+		t.Errorf("Scope() = %q, want %q", got, want)
+	}
+
+	scoped, err := NewScopedSigner(Config{}, key)
+	if err != nil {
+		t.Fatalf("NewScopedSigner failed: %v", err)
+	}
+
+	// Requests signed with the scoped key verify against the secret.
+	v, err := NewVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	later := signingTime.Add(6 * time.Hour)
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if err := scoped.SignHTTP(req, EmptyStringSHA256, later); err != nil {
+		t.Fatalf("SignHTTP failed: %v", err)
+	}
+	if err := v.VerifyHTTP(req, EmptyStringSHA256, later); err != nil {
+		t.Errorf("VerifyHTTP failed: %v", err)
+	}
+
+	// Signing on another day is refused.
+	nextDay := signingTime.AddDate(0, 0, 1)
+	var scopeErr *ScopeError
+
+	req, _ = http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	err = scoped.SignHTTP(req, EmptyStringSHA256, nextDay)
+	if !errors.As(err, &scopeErr) {
+		t.Fatalf("expected *ScopeError, got %v", err)
+	}
+	if scopeErr.Scope != "20240116/us-east-1/s3" || scopeErr.KeyScope != key.Scope() {
+		t.Errorf("unexpected ScopeError %+v", scopeErr)
+	}
+
+	if _, _, err := scoped.PresignHTTP(req, UnsignedPayload, nextDay); !errors.As(err, &scopeErr) {
+		t.Errorf("expected PresignHTTP *ScopeError, got %v", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodPost, "https://bucket.example.com/stream", nil)
+	ss, err := scoped.SignStreamHTTP(req, signingTime)
+	if err != nil {
+		t.Fatalf("SignStreamHTTP failed: %v", err)
+	}
+	if _, err := ss.SignPayload([]byte("event"), nextDay); !errors.As(err, &scopeErr) {
+		t.Errorf("expected SignPayload *ScopeError, got %v", err)
+	}
+}
+
+func TestNewScopedSignerValidation(t *testing.T) {
+	key := ScopedKey{
+		AccessKeyID: "AKID",
+		Date:        "20240115",
+		Region:      "us-east-1",
+		Service:     "s3",
+		Key:         make([]byte, 32),
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		key    func(k ScopedKey) ScopedKey
+	}{
+		{"bad date", Config{}, func(k ScopedKey) ScopedKey { k.Date = "2024-01-15"; return k }},
+		{"empty key", Config{}, func(k ScopedKey) ScopedKey { k.Key = nil; return k }},
+		{"secret", Config{SecretAccessKey: "SECRET"}, func(k ScopedKey) ScopedKey { return k }},
+		{"region mismatch", Config{Region: "eu-west-1"}, func(k ScopedKey) ScopedKey { return k }},
+		{"service mismatch", Config{Service: "sqs"}, func(k ScopedKey) ScopedKey { return k }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewScopedSigner(tt.config, tt.key(key)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	if _, err := NewScopedSigner(Config{Region: "us-east-1"}, key); err != nil {
+		t.Errorf("expected matching region to be accepted, got %v", err)
+	}
+}
+
+func TestScopedSignerProfile(t *testing.T) {
+	config := testConfig
+	config.Profile = GCSProfile
+	full, err := NewSigner(config)
+	if err != nil {
+		t.Fatalf("NewSigner failed: %v", err)
+	}
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	key, err := full.ScopedKey(signingTime)
+	if err != nil {
+		t.Fatalf("ScopedKey failed: %v", err)
+	}
+	if key.Profile != GCSProfile {
+		t.Errorf("Profile = %v, want GCSProfile", key.Profile)
+	}
+
+	// A GCS key signs nothing for the default profile.
+	var scopeErr *ScopeError
+	_, err = NewScopedSigner(Config{}, key)
+	if !errors.As(err, &scopeErr) {
+		t.Fatalf("expected *ScopeError, got %v", err)
+	}
+	if scopeErr.KeyScope != "20240115/us-east-1/s3/goog4_request" || scopeErr.Scope != "20240115/us-east-1/s3/aws4_request" {
+		t.Errorf("unexpected ScopeError %+v", scopeErr)
+	}
+
+	scoped, err := NewScopedSigner(Config{Profile: GCSProfile}, key)
+	if err != nil {
+		t.Fatalf("NewScopedSigner failed: %v", err)
+	}
+	v, err := NewVerifier(config)
+	if err != nil {
+		t.Fatalf("NewVerifier failed: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://bucket.example.com/key", nil)
+	if err := scoped.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+		t.Fatalf("SignHTTP failed: %v", err)
+	}
+	if err := v.VerifyHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+		t.Errorf("VerifyHTTP failed: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"sort"
@@ -20,7 +21,10 @@ import (
 // Reference: AWS SDK v4 signer v4.go Signer struct
 type Signer struct {
 	config       Config
-	keyDerivator keyDerivator
+	keyDerivator KeyDeriver
+	signRule     Rule
+	hoistRule    Rule
+	omitLength   bool
 }
 
 // NewSigner creates a new Signer with the given config.
@@ -28,22 +32,48 @@ type Signer struct {
 // or non-thread-safe cache implementation is used.
 func NewSigner(config Config) (*Signer, error) {
 	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
-	}
-
-	var cache derivedKeyCacheInterface
-	if config.ThreadSafety {
-		cache = newDerivedKeyCacheThr()
-	} else {
-		cache = newDerivedKeyCacheNoThr()
+		return nil, err
 	}
 
 	return &Signer{
 		config:       config,
-		keyDerivator: NewSigningKeyDeriver(cache),
+		keyDerivator: newKeyDerivator(config),
+		signRule:     config.HeaderRules.signRule(config.Profile),
+		hoistRule:    config.HeaderRules.hoistRule(),
+		omitLength:   !config.HeaderRules.signContentLength(),
 	}, nil
 }
 
+// KeyCacheStats returns the hit, miss and eviction counts of the Signer's
+// derived key cache.
+func (s *Signer) KeyCacheStats() KeyCacheStats {
+	if d, ok := s.keyDerivator.(*SigningKeyDeriver); ok {
+		return d.Stats()
+	}
+	return KeyCacheStats{}
+}
+
+// Close zeroes the derived signing keys cached by the Signer, or the key
+// of a scoped Signer, after which signing fails. Close must not be called
+// while requests are being signed. The secret and session token in the
+// Config are Go strings and cannot be zeroed.
+func (s *Signer) Close() error {
+	if c, ok := s.keyDerivator.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// String formats the Signer with the secrets of its Config redacted.
+func (s *Signer) String() string {
+	return "Signer{" + s.config.String() + "}"
+}
+
+// GoString formats the Signer for %#v as String does.
+func (s *Signer) GoString() string {
+	return "&signer." + s.String()
+}
+
 // httpSigner handles the signing process for a single request.
 // Reference: AWS SDK v4 signer v4.go httpSigner struct
 type httpSigner struct {
@@ -53,22 +83,52 @@ type httpSigner struct {
 	Time                  SigningTime
 	AccessKeyID           string
 	SecretAccessKey       string
-	KeyDerivator          keyDerivator
+	SessionToken          string
+	Profile               Profile
+	SignRule              Rule
+	HoistRule             Rule
+	OmitContentLength     bool
+	KeyDerivator          KeyDeriver
 	IsPreSign             bool
 	PayloadHash           string
 	DisableHeaderHoisting bool
+	Logger                *slog.Logger
+	Observer              Observer
+
+	// Signature is the raw signature computed by build.
+	Signature [sha256.Size]byte
 }
 
 // SignHTTP signs an HTTP request using AWS Signature Version 4.
 // The request is modified in place with the Authorization header.
-// The payloadHash must be provided (hex-encoded SHA256 of request body).
+// The payloadHash must be provided (hex-encoded SHA256 of request body),
+// or ErrMissingPayloadHash is returned.
 // For requests with no body, use EmptyStringSHA256.
+// Requests the service would reject whatever their signature, such as one
+// without a host or with an X-Amz-Content-Sha256 header other than
+// payloadHash, fail with a *RequestError before signing.
 // Reference: AWS SDK v4 signer v4.go SignHTTP method
-func (s *Signer) SignHTTP(req *http.Request, payloadHash string, signingTime time.Time) error {
+func (s *Signer) SignHTTP(req *http.Request, payloadHash string, signingTime time.Time) (err error) {
+	if o := s.config.Observer; o != nil {
+		defer observeSince(requestContext(req), o, OperationSign, time.Now(), &err)
+	}
 	if payloadHash == "" {
-		return fmt.Errorf("payload hash is required")
+		return ErrMissingPayloadHash
 	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+	if err := validatePayload(req, payloadHash, s.config.Profile); err != nil {
+		return err
+	}
+	if err := s.config.checkExpiry(signingTime); err != nil {
+		return err
+	}
+	return s.signHTTP(req, payloadHash, signingTime)
+}
 
+// signHTTP signs req as SignHTTP does, without validating it.
+func (s *Signer) signHTTP(req *http.Request, payloadHash string, signingTime time.Time) error {
 	signer := &httpSigner{
 		Request:               req,
 		PayloadHash:           payloadHash,
@@ -76,9 +136,16 @@ func (s *Signer) SignHTTP(req *http.Request, payloadHash string, signingTime tim
 		Region:                s.config.Region,
 		AccessKeyID:           s.config.AccessKeyID,
 		SecretAccessKey:       s.config.SecretAccessKey,
+		SessionToken:          s.config.SessionToken,
+		Profile:               s.config.Profile,
+		SignRule:              s.signRule,
+		HoistRule:             s.hoistRule,
+		OmitContentLength:     s.omitLength,
 		Time:                  NewSigningTime(signingTime),
 		DisableHeaderHoisting: s.config.DisableHeaderHoisting,
 		KeyDerivator:          s.keyDerivator,
+		Logger:                s.config.Logger,
+		Observer:              s.config.Observer,
 	}
 
 	return signer.build()
@@ -86,11 +153,22 @@ func (s *Signer) SignHTTP(req *http.Request, payloadHash string, signingTime tim
 
 // PresignHTTP presigns an HTTP request using AWS Signature Version 4.
 // Returns the signed URL, signed headers that must be included, and error.
-// The request is cloned and not modified.
+// The request is cloned and not modified. It is validated as by SignHTTP,
+// except for the body and X-Amz-Content-Sha256, which are not sent with
+// the presigned URL.
 // Reference: AWS SDK v4 signer v4.go PresignHTTP method
-func (s *Signer) PresignHTTP(req *http.Request, payloadHash string, signingTime time.Time) (string, http.Header, error) {
+func (s *Signer) PresignHTTP(req *http.Request, payloadHash string, signingTime time.Time) (signedURL string, signedHeaders http.Header, err error) {
+	if o := s.config.Observer; o != nil {
+		defer observeSince(requestContext(req), o, OperationPresign, time.Now(), &err)
+	}
 	if payloadHash == "" {
-		return "", nil, fmt.Errorf("payload hash is required")
+		return "", nil, ErrMissingPayloadHash
+	}
+	if err := validateRequest(req); err != nil {
+		return "", nil, err
+	}
+	if err := s.config.checkExpiry(signingTime); err != nil {
+		return "", nil, err
 	}
 
 	// Clone the request to avoid modifying the original
@@ -119,13 +197,20 @@ func (s *Signer) PresignHTTP(req *http.Request, payloadHash string, signingTime
 		Region:                s.config.Region,
 		AccessKeyID:           s.config.AccessKeyID,
 		SecretAccessKey:       s.config.SecretAccessKey,
+		SessionToken:          s.config.SessionToken,
+		Profile:               s.config.Profile,
+		SignRule:              s.signRule,
+		HoistRule:             s.hoistRule,
+		OmitContentLength:     s.omitLength,
 		Time:                  NewSigningTime(signingTime),
 		IsPreSign:             true,
 		DisableHeaderHoisting: s.config.DisableHeaderHoisting,
 		KeyDerivator:          s.keyDerivator,
+		Logger:                s.config.Logger,
+		Observer:              s.config.Observer,
 	}
 
-	signedHeaders, err := signer.buildPresign()
+	signedHeaders, err = signer.buildPresign()
 	if err != nil {
 		return "", nil, err
 	}
@@ -140,78 +225,110 @@ func (s *Signer) PresignHTTP(req *http.Request, payloadHash string, signingTime
 	return clonedReq.URL.String(), resultHeaders, nil
 }
 
-// build performs the signing process for SignHTTP.
+// build performs the signing process for SignHTTP. The canonical request
+// is written into a pooled buffer and hashed without building the
+// intermediate strings, producing the same signature as the Build*
+// functions. The signature is kept in s.Signature to seed the chain of an
+// event stream.
 func (s *httpSigner) build() error {
 	req := s.Request
-	query := req.URL.Query()
 	headers := req.Header
 
-	s.setRequiredSigningFields(headers, query)
+	s.setRequiredSigningFields(headers, nil)
 
 	// Sort query values
-	for key := range query {
-		sort.Strings(query[key])
+	if req.URL.RawQuery != "" {
+		query := req.URL.Query()
+		for key := range query {
+			sort.Strings(query[key])
+		}
+		req.URL.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
 	}
 
 	SanitizeHostForHeader(req)
 
-	credentialScope := BuildCredentialScope(s.Time, s.Region, s.ServiceName)
-	credentialStr := s.AccessKeyID + "/" + credentialScope
-
 	host := req.URL.Host
 	if len(req.Host) > 0 {
 		host = req.Host
 	}
+	length := s.contentLength()
+	amzDate := s.Time.TimeFormat()
 
-	_, signedHeadersStr, canonicalHeaderStr := BuildCanonicalHeaders(
-		host,
-		IgnoredHeaders,
-		headers,
-		req.ContentLength,
-	)
-
-	var rawQuery strings.Builder
-	rawQuery.WriteString(
-		strings.Replace(query.Encode(), "+", "%20", -1),
-	)
+	b := getSignBuffer()
+	defer b.release()
 
-	canonicalURI := GetURIPath(req.URL)
+	// Canonical request:
+	// METHOD\nURI\nQUERY\nCANONICAL_HEADERS\nSIGNED_HEADERS\nPAYLOAD_HASH
+	b.selectHeaders(s.SignRule, headers, length)
+	b.buf = append(b.buf, req.Method...)
+	b.buf = append(b.buf, '\n')
 	// Note: URI path escaping is disabled for S3/R2 compatibility
-
-	canonicalString := BuildCanonicalString(
-		req.Method,
-		canonicalURI,
-		rawQuery.String(),
-		signedHeadersStr,
-		canonicalHeaderStr,
-		s.PayloadHash,
-	)
-
-	strToSign := BuildStringToSign(
-		SigningAlgorithm,
-		s.Time.TimeFormat(),
-		credentialScope,
-		canonicalString,
-	)
-
-	key := s.KeyDerivator.DeriveKey(
+	b.buf = append(b.buf, GetURIPath(req.URL)...)
+	b.buf = append(b.buf, '\n')
+	b.buf = append(b.buf, req.URL.RawQuery...)
+	b.buf = append(b.buf, '\n')
+	b.appendCanonicalHeaders(host, headers, length)
+	b.buf = append(b.buf, '\n')
+	signedStart := len(b.buf)
+	b.appendSignedHeaders()
+	signedEnd := len(b.buf)
+	b.buf = append(b.buf, '\n')
+	b.buf = append(b.buf, s.PayloadHash...)
+	canonicalHash := b.hashHex(b.buf)
+
+	// String to sign: ALGORITHM\nTIMESTAMP\nSCOPE\nHASH(CANONICAL_REQUEST)
+	stsStart := len(b.buf)
+	b.buf = append(b.buf, s.Profile.Algorithm...)
+	b.buf = append(b.buf, '\n')
+	b.buf = append(b.buf, amzDate...)
+	b.buf = append(b.buf, '\n')
+	scopeStart := len(b.buf)
+	b.buf = append(b.buf, amzDate[:len(ShortTimeFormat)]...)
+	b.buf = append(b.buf, '/')
+	b.buf = append(b.buf, s.Region...)
+	b.buf = append(b.buf, '/')
+	b.buf = append(b.buf, s.ServiceName...)
+	b.buf = append(b.buf, '/')
+	b.buf = append(b.buf, s.Profile.Terminator...)
+	scopeEnd := len(b.buf)
+	b.buf = append(b.buf, '\n')
+	b.buf = append(b.buf, canonicalHash...)
+
+	key, cached, err := deriveSigningKey(
+		req.Context(),
+		s.Observer,
+		s.KeyDerivator,
 		s.AccessKeyID,
 		s.SecretAccessKey,
 		s.ServiceName,
 		s.Region,
 		s.Time,
 	)
+	if err != nil {
+		return err
+	}
 
-	signature := BuildSignature(key, strToSign)
-
-	authHeader := BuildAuthorizationHeader(
-		credentialStr,
-		signedHeadersStr,
-		signature,
-	)
+	mac, signature := b.hmacHex(key, b.buf[stsStart:])
+	copy(s.Signature[:], mac)
+
+	// Format: ALGORITHM Credential=..., SignedHeaders=..., Signature=...
+	authStart := len(b.buf)
+	b.buf = append(b.buf, s.Profile.Algorithm...)
+	b.buf = append(b.buf, " Credential="...)
+	b.buf = append(b.buf, s.AccessKeyID...)
+	b.buf = append(b.buf, '/')
+	b.buf = append(b.buf, b.buf[scopeStart:scopeEnd]...)
+	b.buf = append(b.buf, ", SignedHeaders="...)
+	b.buf = append(b.buf, b.buf[signedStart:signedEnd]...)
+	b.buf = append(b.buf, ", Signature="...)
+	b.buf = append(b.buf, signature...)
+
+	if ctx := req.Context(); debugEnabled(ctx, s.Logger) {
+		s.event(string(b.buf[scopeStart:scopeEnd]), string(b.buf[signedStart:signedEnd]), string(signature), cached).
+			log(ctx, s.Logger, "signed request", nil)
+	}
 
-	headers[AuthorizationHeader] = []string{authHeader}
-	req.URL.RawQuery = rawQuery.String()
+	headers[AuthorizationHeader] = []string{string(b.buf[authStart:])}
 
 	return nil
 }
@@ -231,14 +348,14 @@ func (s *httpSigner) buildPresign() (http.Header, error) {
 
 	SanitizeHostForHeader(req)
 
-	credentialScope := BuildCredentialScope(s.Time, s.Region, s.ServiceName)
+	credentialScope := s.Profile.CredentialScope(s.Time, s.Region, s.ServiceName)
 	credentialStr := s.AccessKeyID + "/" + credentialScope
-	query.Set(AmzCredentialKey, credentialStr)
+	query.Set(s.Profile.CredentialKey(), credentialStr)
 
 	unsignedHeaders := headers
 	if !s.DisableHeaderHoisting {
 		urlValues, uHeaders := BuildQuery(
-			AllowedQueryHoisting,
+			s.HoistRule,
 			headers,
 		)
 		for k := range urlValues {
@@ -254,12 +371,12 @@ func (s *httpSigner) buildPresign() (http.Header, error) {
 
 	signedHeaders, signedHeadersStr, canonicalHeaderStr := BuildCanonicalHeaders(
 		host,
-		IgnoredHeaders,
+		s.SignRule,
 		unsignedHeaders,
-		req.ContentLength,
+		s.contentLength(),
 	)
 
-	query.Set(AmzSignedHeadersKey, signedHeadersStr)
+	query.Set(s.Profile.SignedHeadersKey(), signedHeadersStr)
 
 	var rawQuery strings.Builder
 	rawQuery.WriteString(
@@ -279,43 +396,84 @@ func (s *httpSigner) buildPresign() (http.Header, error) {
 	)
 
 	strToSign := BuildStringToSign(
-		SigningAlgorithm,
+		s.Profile.Algorithm,
 		s.Time.TimeFormat(),
 		credentialScope,
 		canonicalString,
 	)
 
-	key := s.KeyDerivator.DeriveKey(
+	key, cached, err := deriveSigningKey(
+		req.Context(),
+		s.Observer,
+		s.KeyDerivator,
 		s.AccessKeyID,
 		s.SecretAccessKey,
 		s.ServiceName,
 		s.Region,
 		s.Time,
 	)
+	if err != nil {
+		return nil, err
+	}
 
 	signature := BuildSignature(key, strToSign)
 
 	rawQuery.WriteString("&")
-	rawQuery.WriteString(AmzSignatureKey)
+	rawQuery.WriteString(s.Profile.SignatureKey())
 	rawQuery.WriteString("=")
 	rawQuery.WriteString(signature)
 
 	req.URL.RawQuery = rawQuery.String()
 
+	if ctx := req.Context(); debugEnabled(ctx, s.Logger) {
+		s.event(credentialScope, signedHeadersStr, signature, cached).
+			log(ctx, s.Logger, "presigned request", nil)
+	}
+
 	return signedHeaders, nil
 }
 
+// event describes the signing of s for debug logging.
+func (s *httpSigner) event(scope, signedHeaders, signature string, cachedKey bool) *signingEvent {
+	return &signingEvent{
+		Method:        s.Request.Method,
+		Host:          GetHost(s.Request),
+		AccessKeyID:   s.AccessKeyID,
+		Scope:         scope,
+		SignedHeaders: signedHeaders,
+		PayloadHash:   s.PayloadHash,
+		Signature:     signature,
+		Presigned:     s.IsPreSign,
+		CachedKey:     cachedKey,
+	}
+}
+
+// contentLength returns the length to sign as Content-Length, or 0 if it
+// is not signed.
+func (s *httpSigner) contentLength() int64 {
+	if s.OmitContentLength {
+		return 0
+	}
+	return s.Request.ContentLength
+}
+
 // setRequiredSigningFields sets required signing fields in headers/query.
 func (s *httpSigner) setRequiredSigningFields(headers http.Header, query url.Values) {
 	amzDate := s.Time.TimeFormat()
 
 	if s.IsPreSign {
-		query.Set(AmzAlgorithmKey, SigningAlgorithm)
-		query.Set(AmzDateKey, amzDate)
+		query.Set(s.Profile.AlgorithmKey(), s.Profile.Algorithm)
+		query.Set(s.Profile.DateKey(), amzDate)
+		if s.SessionToken != "" {
+			query.Set(s.Profile.SecurityTokenKey(), s.SessionToken)
+		}
 		return
 	}
 
-	headers[AmzDateKey] = []string{amzDate}
+	headers[s.Profile.DateKey()] = []string{amzDate}
+	if s.SessionToken != "" {
+		headers[s.Profile.SecurityTokenKey()] = []string{s.SessionToken}
+	}
 }
 
 // ComputePayloadHash computes the SHA256 hash of the request body.
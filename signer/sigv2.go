@@ -0,0 +1,283 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// V2AccessKeyIDKey is the SigV2 query parameter key for the access key.
+	V2AccessKeyIDKey = "AWSAccessKeyId"
+
+	// V2ExpiresKey is the SigV2 query parameter key for the expiry time,
+	// given in seconds since the Unix epoch.
+	V2ExpiresKey = "Expires"
+
+	// V2SignatureKey is the SigV2 query parameter key for the signature.
+	V2SignatureKey = "Signature"
+)
+
+// v2SubResources are the S3 sub-resources included in the canonicalized
+// resource of a SigV2 string to sign.
+var v2SubResources = map[string]struct{}{
+	"acl": {}, "cors": {}, "delete": {}, "lifecycle": {}, "location": {},
+	"logging": {}, "notification": {}, "partNumber": {}, "policy": {},
+	"requestPayment": {}, "restore": {}, "tagging": {}, "torrent": {},
+	"uploadId": {}, "uploads": {}, "versionId": {}, "versioning": {},
+	"versions": {}, "website": {},
+	"response-cache-control": {}, "response-content-disposition": {},
+	"response-content-encoding": {}, "response-content-language": {},
+	"response-content-type": {}, "response-expires": {},
+}
+
+// V2Signer signs S3 requests with the legacy AWS Signature Version 2, for
+// older S3-compatible stores that do not support SigV4. New code should
+// use Signer. The bucket is signed as part of the resource: requests are
+// taken to address buckets path-style unless ServiceHost is set.
+// Config.Region and Config.Service are not part of a SigV2 signature. A
+// V2Signer is safe for concurrent use.
+// Reference: Amazon S3 Developer Guide, "Signing and authenticating REST
+// requests" (legacy)
+type V2Signer struct {
+	// ServiceHost is the host of the service endpoint, e.g.
+	// "s3.amazonaws.com", for signing virtual-hosted requests such as
+	// S3Endpoint makes by default. When set, the bucket of a request to a
+	// subdomain of it is taken from the host, and requests to other hosts
+	// fail with a *RequestError.
+	ServiceHost string
+
+	config Config
+}
+
+// NewV2Signer creates a legacy SigV2 signer.
+func NewV2Signer(config Config) (*V2Signer, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.SecretAccessKey == "" {
+		// SigV2 signs with the secret itself, so a KeyDeriver cannot be used.
+		return nil, &ConfigError{Field: "SecretAccessKey", Reason: "is required"}
+	}
+	return &V2Signer{config: config}, nil
+}
+
+// String formats the V2Signer with the secrets of its Config redacted.
+func (s *V2Signer) String() string {
+	return "V2Signer{" + s.config.String() + "}"
+}
+
+// GoString formats the V2Signer for %#v as String does.
+func (s *V2Signer) GoString() string {
+	return "&signer." + s.String()
+}
+
+// SignHTTP signs req in place, setting the Date header to signingTime
+// and the "AWS AccessKeyID:Signature" Authorization header. Requests are
+// validated as by Signer.SignHTTP, except for their body.
+func (s *V2Signer) SignHTTP(req *http.Request, signingTime time.Time) (err error) {
+	if o := s.config.Observer; o != nil {
+		defer observeSince(requestContext(req), o, OperationSign, time.Now(), &err)
+	}
+	if err := validateRequest(req); err != nil {
+		return err
+	}
+	if err := s.config.checkExpiry(signingTime); err != nil {
+		return err
+	}
+	bucket, err := s.bucket(req)
+	if err != nil {
+		return err
+	}
+	SanitizeHostForHeader(req)
+
+	date := signingTime.UTC().Format(http.TimeFormat)
+	req.Header.Set("Date", date)
+	if s.config.SessionToken != "" {
+		req.Header.Set(AmzSecurityTokenKey, s.config.SessionToken)
+	}
+	if req.Header.Get(AmzDateKey) != "" {
+		// X-Amz-Date is signed as an amz header in place of Date.
+		date = ""
+	}
+
+	signature := s.sign(buildV2StringToSign(req, date, bucket))
+	req.Header.Set(AuthorizationHeader, "AWS "+s.config.AccessKeyID+":"+signature)
+	s.log(req, signature, false)
+	return nil
+}
+
+// PresignHTTP returns a URL for req authenticated with the
+// AWSAccessKeyId, Expires and Signature query parameters, valid until
+// expires. Headers of req that are part of the string to sign, such as
+// Content-Type and X-Amz-*, must be sent with the URL. The request is not
+// modified. It is validated as by SignHTTP, and presigning fails once the
+// credentials have expired by Config.Expires.
+func (s *V2Signer) PresignHTTP(req *http.Request, expires time.Time) (signedURL string, err error) {
+	if o := s.config.Observer; o != nil {
+		defer observeSince(requestContext(req), o, OperationPresign, time.Now(), &err)
+	}
+	if err := validateRequest(req); err != nil {
+		return "", err
+	}
+	if err := s.config.checkExpiry(time.Now()); err != nil {
+		return "", err
+	}
+	bucket, err := s.bucket(req)
+	if err != nil {
+		return "", err
+	}
+
+	clonedReq := req.Clone(req.Context())
+	SanitizeHostForHeader(clonedReq)
+	if s.config.SessionToken != "" {
+		clonedReq.Header.Set(AmzSecurityTokenKey, s.config.SessionToken)
+	}
+
+	expiresStr := strconv.FormatInt(expires.Unix(), 10)
+	signature := s.sign(buildV2StringToSign(clonedReq, expiresStr, bucket))
+
+	query := clonedReq.URL.Query()
+	query.Set(V2AccessKeyIDKey, s.config.AccessKeyID)
+	query.Set(V2ExpiresKey, expiresStr)
+	query.Set(V2SignatureKey, signature)
+	if s.config.SessionToken != "" {
+		query.Set(AmzSecurityTokenKey, s.config.SessionToken)
+	}
+	clonedReq.URL.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
+	clonedReq.URL.Host = GetHost(clonedReq)
+
+	s.log(clonedReq, signature, true)
+	return clonedReq.URL.String(), nil
+}
+
+// bucket returns the bucket of a virtual-hosted request, the subdomain of
+// ServiceHost it is sent to, or "" for a path-style request.
+func (s *V2Signer) bucket(req *http.Request) (string, error) {
+	if s.ServiceHost == "" {
+		return "", nil
+	}
+	host := StripPort(GetHost(req))
+	serviceHost := StripPort(s.ServiceHost)
+	if strings.EqualFold(host, serviceHost) {
+		return "", nil
+	}
+	if n := len(host) - len(serviceHost) - 1; n > 0 && host[n] == '.' && strings.EqualFold(host[n+1:], serviceHost) {
+		return host[:n], nil
+	}
+	return "", &RequestError{Field: "Host", Reason: fmt.Sprintf("%q is not ServiceHost %q or a bucket subdomain of it", host, s.ServiceHost)}
+}
+
+// log records the signing of req at debug level.
+func (s *V2Signer) log(req *http.Request, signature string, presigned bool) {
+	if ctx := req.Context(); debugEnabled(ctx, s.config.Logger) {
+		msg := "signed request"
+		if presigned {
+			msg = "presigned request"
+		}
+		ev := &signingEvent{
+			Method:      req.Method,
+			Host:        GetHost(req),
+			AccessKeyID: s.config.AccessKeyID,
+			Signature:   signature,
+			Presigned:   presigned,
+		}
+		ev.log(ctx, s.config.Logger, msg, nil)
+	}
+}
+
+// sign returns the base64 HMAC-SHA1 of stringToSign.
+func (s *V2Signer) sign(stringToSign string) string {
+	h := hmac.New(sha1.New, []byte(s.config.SecretAccessKey))
+	h.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// buildV2StringToSign builds the SigV2 string to sign, where date is the
+// Date header, or the Expires value when presigning, and bucket that of a
+// virtual-hosted request.
+// Format: METHOD\nCONTENT-MD5\nCONTENT-TYPE\nDATE\nAMZ_HEADERSRESOURCE
+func buildV2StringToSign(req *http.Request, date, bucket string) string {
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte('\n')
+	b.WriteString(req.Header.Get("Content-Md5"))
+	b.WriteByte('\n')
+	b.WriteString(req.Header.Get("Content-Type"))
+	b.WriteByte('\n')
+	b.WriteString(date)
+	b.WriteByte('\n')
+	b.WriteString(buildV2CanonicalAmzHeaders(req.Header))
+	b.WriteString(buildV2CanonicalResource(req.URL, bucket))
+	return b.String()
+}
+
+// buildV2CanonicalAmzHeaders returns the x-amz-* headers lower cased and
+// sorted, each on its own line with values joined by commas.
+func buildV2CanonicalAmzHeaders(header http.Header) string {
+	var keys []string
+	values := make(map[string][]string)
+	for k, v := range header {
+		lowerKey := strings.ToLower(k)
+		if !strings.HasPrefix(lowerKey, "x-amz-") {
+			continue
+		}
+		if _, ok := values[lowerKey]; !ok {
+			keys = append(keys, lowerKey)
+		}
+		for _, value := range v {
+			values[lowerKey] = append(values[lowerKey], StripExcessSpaces(value))
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(k)
+		b.WriteByte(':')
+		b.WriteString(strings.Join(values[k], ","))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// buildV2CanonicalResource returns the escaped path, prefixed with
+// "/bucket" for a virtual-hosted request, followed by the sorted S3
+// sub-resources of the query, with their unescaped values.
+func buildV2CanonicalResource(u *url.URL, bucket string) string {
+	query := u.Query()
+	var keys []string
+	for k := range query {
+		if _, ok := v2SubResources[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	if bucket != "" {
+		b.WriteByte('/')
+		b.WriteString(bucket)
+	}
+	b.WriteString(GetURIPath(u))
+	for i, k := range keys {
+		if i == 0 {
+			b.WriteByte('?')
+		} else {
+			b.WriteByte('&')
+		}
+		b.WriteString(k)
+		if v := query.Get(k); v != "" {
+			b.WriteByte('=')
+			b.WriteString(v)
+		}
+	}
+	return b.String()
+}
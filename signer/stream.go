@@ -0,0 +1,186 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/forestrie/go-sigv4/eventstream"
+)
+
+const (
+	// StreamingEventsPayload is the payload hash of a request whose body
+	// is an event stream of signed messages.
+	StreamingEventsPayload = "STREAMING-AWS4-HMAC-SHA256-EVENTS"
+
+	// StreamPayloadAlgorithm is the algorithm of event stream message
+	// signatures.
+	StreamPayloadAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+
+	// ChunkSignatureHeader is the event stream header carrying a
+	// message signature.
+	ChunkSignatureHeader = ":chunk-signature"
+
+	// StreamDateHeader is the event stream header carrying the time a
+	// message was signed.
+	StreamDateHeader = ":date"
+)
+
+// SignStreamHTTP signs a request whose body is an event stream, as
+// SignHTTP does with StreamingEventsPayload as the payload hash, and
+// returns a StreamSigner seeded with the request signature for signing
+// the messages of the body.
+// Reference: AWS SDK for Go v2 aws/signer/v4 StreamSigner
+func (s *Signer) SignStreamHTTP(req *http.Request, signingTime time.Time) (ss *StreamSigner, err error) {
+	if o := s.config.Observer; o != nil {
+		defer observeSince(requestContext(req), o, OperationSign, time.Now(), &err)
+	}
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+	if err := validatePayload(req, StreamingEventsPayload, s.config.Profile); err != nil {
+		return nil, err
+	}
+	if err := s.config.checkExpiry(signingTime); err != nil {
+		return nil, err
+	}
+	req.Header.Set(ContentSHAKey, StreamingEventsPayload)
+
+	signer := &httpSigner{
+		Request:               req,
+		PayloadHash:           StreamingEventsPayload,
+		ServiceName:           s.config.Service,
+		Region:                s.config.Region,
+		AccessKeyID:           s.config.AccessKeyID,
+		SecretAccessKey:       s.config.SecretAccessKey,
+		SessionToken:          s.config.SessionToken,
+		Profile:               s.config.Profile,
+		SignRule:              s.signRule,
+		HoistRule:             s.hoistRule,
+		OmitContentLength:     s.omitLength,
+		Time:                  NewSigningTime(signingTime),
+		DisableHeaderHoisting: s.config.DisableHeaderHoisting,
+		KeyDerivator:          s.keyDerivator,
+		Logger:                s.config.Logger,
+		Observer:              s.config.Observer,
+	}
+
+	if err := signer.build(); err != nil {
+		return nil, err
+	}
+	return s.NewStreamSigner(signer.Signature[:]), nil
+}
+
+// StreamSigner signs event stream messages. Each signature covers the
+// previous one, starting from the seed, so messages must be signed in
+// the order they are sent and a StreamSigner must not be shared between
+// goroutines.
+type StreamSigner struct {
+	config        Config
+	keyDerivator  KeyDeriver
+	prevSignature []byte
+	headers       bytes.Buffer
+}
+
+// NewStreamSigner returns a StreamSigner whose chain starts at
+// seedSignature, the binary signature of the request that opened the
+// stream.
+func (s *Signer) NewStreamSigner(seedSignature []byte) *StreamSigner {
+	return &StreamSigner{
+		config:        s.config,
+		keyDerivator:  s.keyDerivator,
+		prevSignature: seedSignature,
+	}
+}
+
+// String formats the StreamSigner with the secrets of its Config redacted.
+func (ss *StreamSigner) String() string {
+	return "StreamSigner{" + ss.config.String() + "}"
+}
+
+// GoString formats the StreamSigner for %#v as String does.
+func (ss *StreamSigner) GoString() string {
+	return "&signer." + ss.String()
+}
+
+// GetSignature returns the signature of a message with the encoded
+// headers and payload, and advances the chain. On error, such as after
+// Close or once the credentials have expired, the chain is unchanged.
+func (ss *StreamSigner) GetSignature(headers, payload []byte, signingTime time.Time) (signature []byte, err error) {
+	if o := ss.config.Observer; o != nil {
+		defer observeSince(context.Background(), o, OperationSignEvent, time.Now(), &err)
+	}
+	if err := ss.config.checkExpiry(signingTime); err != nil {
+		return nil, err
+	}
+	st := NewSigningTime(signingTime)
+	credentialScope := ss.config.Profile.CredentialScope(st, ss.config.Region, ss.config.Service)
+
+	headersHash := sha256.Sum256(headers)
+	payloadHash := sha256.Sum256(payload)
+	strToSign := strings.Join([]string{
+		StreamPayloadAlgorithm,
+		st.TimeFormat(),
+		credentialScope,
+		hex.EncodeToString(ss.prevSignature),
+		hex.EncodeToString(headersHash[:]),
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	key, _, err := deriveSigningKey(
+		context.Background(),
+		ss.config.Observer,
+		ss.keyDerivator,
+		ss.config.AccessKeyID,
+		ss.config.SecretAccessKey,
+		ss.config.Service,
+		ss.config.Region,
+		st,
+	)
+	if err != nil {
+		return nil, err
+	}
+	signature = HMACSHA256(key, []byte(strToSign))
+	ss.prevSignature = signature
+	return signature, nil
+}
+
+// SignPayload wraps payload, usually an encoded event stream message, in
+// a signed message carrying the :date and :chunk-signature headers. The
+// stream is ended by signing an empty payload.
+func (ss *StreamSigner) SignPayload(payload []byte, signingTime time.Time) (eventstream.Message, error) {
+	// The :date header has millisecond precision, the signature second.
+	signingTime = signingTime.UTC().Truncate(time.Millisecond)
+	date := eventstream.Headers{{Name: StreamDateHeader, Value: eventstream.TimestampValue(signingTime)}}
+
+	ss.headers.Reset()
+	if err := eventstream.EncodeHeaders(&ss.headers, date); err != nil {
+		return eventstream.Message{}, fmt.Errorf("failed to encode date header: %w", err)
+	}
+	signature, err := ss.GetSignature(ss.headers.Bytes(), payload, signingTime)
+	if err != nil {
+		return eventstream.Message{}, err
+	}
+
+	return eventstream.Message{
+		Headers: append(date, eventstream.Header{
+			Name:  ChunkSignatureHeader,
+			Value: eventstream.BytesValue(signature),
+		}),
+		Payload: payload,
+	}, nil
+}
+
+// SignMessage encodes msg and wraps it in a signed message.
+func (ss *StreamSigner) SignMessage(msg eventstream.Message, signingTime time.Time) (eventstream.Message, error) {
+	payload, err := eventstream.EncodeMessage(msg)
+	if err != nil {
+		return eventstream.Message{}, err
+	}
+	return ss.SignPayload(payload, signingTime)
+}
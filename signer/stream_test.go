@@ -0,0 +1,174 @@
+package signer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/forestrie/go-sigv4/eventstream"
+)
+
+func TestSignStreamHTTP(t *testing.T) {
+	config := testConfig
+	config.Service = "transcribe"
+	signer, _ := NewSigner(config)
+	signingTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	req, _ := buildTestRequest("POST", "https://transcribestreaming.us-east-1.amazonaws.com/stream-transcription", "")
+	req.Header.Set("Content-Type", eventstream.ContentType)
+	ss, err := signer.SignStreamHTTP(req, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if req.Header.Get(ContentSHAKey) != StreamingEventsPayload {
+		t.Errorf("expected %s to be %s", ContentSHAKey, StreamingEventsPayload)
+	}
+
+	// The request verifies with the streaming payload hash.
+	verifier, _ := NewVerifier(config)
+	if err := verifier.VerifyHTTP(serverSide(t, req), StreamingEventsPayload, signingTime); err != nil {
+		t.Fatalf("expected request to verify, got %v", err)
+	}
+
+	// Sign two events and the end of stream.
+	var body bytes.Buffer
+	enc := eventstream.NewEncoder(&body)
+	events := []string{"audio-1", "audio-2"}
+	for i, data := range events {
+		signed, err := ss.SignMessage(eventstream.Message{
+			Headers: eventstream.Headers{{Name: ":event-type", Value: eventstream.StringValue("AudioEvent")}},
+			Payload: []byte(data),
+		}, signingTime.Add(time.Duration(i)*time.Second))
+		if err != nil {
+			t.Fatalf("failed to sign message: %v", err)
+		}
+		enc.Encode(signed)
+	}
+	end, _ := ss.SignPayload(nil, signingTime.Add(2*time.Second))
+	enc.Encode(end)
+
+	// Check the chain as the service would, seeded from the request signature.
+	auth := req.Header.Get(AuthorizationHeader)
+	prev, _ := hex.DecodeString(auth[strings.LastIndex(auth, "=")+1:])
+	dec := eventstream.NewDecoder(&body)
+	for i := 0; i < 3; i++ {
+		msg, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("failed to decode message %d: %v", i, err)
+		}
+		date := time.Time(msg.Headers.Get(StreamDateHeader).(eventstream.TimestampValue))
+		signature := []byte(msg.Headers.Get(ChunkSignatureHeader).(eventstream.BytesValue))
+
+		var headers bytes.Buffer
+		eventstream.EncodeHeaders(&headers, msg.Headers[:1])
+		st := NewSigningTime(date)
+		headersHash := sha256.Sum256(headers.Bytes())
+		payloadHash := sha256.Sum256(msg.Payload)
+		strToSign := StreamPayloadAlgorithm + "\n" + st.TimeFormat() + "\n" +
+			BuildCredentialScope(st, config.Region, config.Service) + "\n" +
+			hex.EncodeToString(prev) + "\n" +
+			hex.EncodeToString(headersHash[:]) + "\n" +
+			hex.EncodeToString(payloadHash[:])
+		expected := HMACSHA256(DeriveKey(config.SecretAccessKey, config.Service, config.Region, st), []byte(strToSign))
+		if !bytes.Equal(signature, expected) {
+			t.Fatalf("message %d: signature mismatch", i)
+		}
+		prev = signature
+
+		if i < len(events) {
+			inner, err := eventstream.DecodeMessage(msg.Payload)
+			if err != nil || string(inner.Payload) != events[i] {
+				t.Errorf("message %d: unexpected inner message %q, %v", i, inner.Payload, err)
+			}
+		} else if len(msg.Payload) != 0 {
+			t.Errorf("expected empty end of stream payload, got %q", msg.Payload)
+		}
+	}
+}
+
+func TestStreamSignerChain(t *testing.T) {
+	signer, _ := NewSigner(testConfig)
+	seed := []byte{1, 2, 3}
+	signingTime := time.Unix(0, 0)
+
+	a := signer.NewStreamSigner(seed)
+	b := signer.NewStreamSigner(seed)
+	sign := func(ss *StreamSigner) []byte {
+		t.Helper()
+		signature, err := ss.GetSignature([]byte("h"), []byte("p1"), signingTime)
+		if err != nil {
+			t.Fatalf("GetSignature failed: %v", err)
+		}
+		return signature
+	}
+	first := sign(a)
+	if !bytes.Equal(first, sign(b)) {
+		t.Fatal("expected signers with the same seed to agree")
+	}
+
+	// Signing the same message again gives a new signature, chained
+	// from the previous one.
+	second := sign(a)
+	if bytes.Equal(first, second) {
+		t.Error("expected chained signature to differ")
+	}
+	other := signer.NewStreamSigner([]byte{4})
+	if bytes.Equal(first, sign(other)) {
+		t.Error("expected signature to depend on the seed")
+	}
+}
+
+func TestStreamSignerErrors(t *testing.T) {
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	config := testConfig
+	config.Expires = signingTime
+	signer, _ := NewSigner(config)
+
+	ss := signer.NewStreamSigner([]byte{1})
+	var expiredErr *ExpiredCredentialsError
+	if sig, err := ss.GetSignature([]byte("h"), []byte("p"), signingTime); !errors.As(err, &expiredErr) || sig != nil {
+		t.Errorf("expected *ExpiredCredentialsError, got %x, %v", sig, err)
+	}
+
+	signer.Close()
+	if sig, err := ss.GetSignature([]byte("h"), []byte("p"), signingTime.Add(-time.Hour)); !errors.Is(err, ErrClosed) || sig != nil {
+		t.Errorf("expected ErrClosed after Close, got %x, %v", sig, err)
+	}
+}
+
+func TestStreamSignerProfile(t *testing.T) {
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	config := testConfig
+	config.Profile = GCSProfile
+	gcs, _ := NewSigner(config)
+	aws, _ := NewSigner(testConfig)
+
+	// The credential scope of the string to sign ends in the profile's
+	// terminator, so the signatures differ even with the same key.
+	gcsSig, err := gcs.NewStreamSigner([]byte{1}).GetSignature([]byte("h"), []byte("p"), signingTime)
+	if err != nil {
+		t.Fatalf("GetSignature failed: %v", err)
+	}
+	key := GCSProfile.DeriveKey(testConfig.SecretAccessKey, testConfig.Service, testConfig.Region, NewSigningTime(signingTime))
+	headersHash := sha256.Sum256([]byte("h"))
+	payloadHash := sha256.Sum256([]byte("p"))
+	strToSign := strings.Join([]string{
+		StreamPayloadAlgorithm,
+		"20240115T120000Z",
+		"20240115/" + testConfig.Region + "/" + testConfig.Service + "/goog4_request",
+		"01",
+		hex.EncodeToString(headersHash[:]),
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	if want := HMACSHA256(key, []byte(strToSign)); !bytes.Equal(gcsSig, want) {
+		t.Errorf("GCS stream signature %x, want %x", gcsSig, want)
+	}
+	awsSig, _ := aws.NewStreamSigner([]byte{1}).GetSignature([]byte("h"), []byte("p"), signingTime)
+	if bytes.Equal(gcsSig, awsSig) {
+		t.Error("expected profile to change the stream signature")
+	}
+}
@@ -0,0 +1,147 @@
+package signer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// validateRequest checks that req can be signed or presigned, returning a
+// *RequestError for a request the service would reject whatever its
+// signature, so that the problem surfaces locally rather than as a 403.
+func validateRequest(req *http.Request) error {
+	if req == nil {
+		return &RequestError{Field: "Request", Reason: "is nil"}
+	}
+	if req.URL == nil {
+		return &RequestError{Field: "URL", Reason: "is nil"}
+	}
+	if req.Header == nil {
+		return &RequestError{Field: "Header", Reason: "is nil"}
+	}
+
+	host := GetHost(req)
+	if host == "" {
+		return &RequestError{Field: "Host", Reason: "is empty"}
+	}
+	// The Host header is not sent; req.Host or the URL host is, and signed
+	// without a default port, as by SanitizeHostForHeader.
+	if h := req.Header.Get("Host"); h != "" && !strings.EqualFold(stripDefaultPort(req.URL.Scheme, h), stripDefaultPort(req.URL.Scheme, host)) {
+		return &RequestError{Field: "Host", Reason: fmt.Sprintf("header %q conflicts with request host %q", h, host)}
+	}
+
+	length := req.ContentLength
+	if length < -1 {
+		return &RequestError{Field: "ContentLength", Reason: fmt.Sprintf("%d is invalid", length)}
+	}
+	if v := req.Header.Get("Content-Length"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err != nil || n != length {
+			return &RequestError{Field: "Content-Length", Reason: fmt.Sprintf("header %q conflicts with ContentLength %d", v, length)}
+		}
+	}
+
+	for name, values := range req.Header {
+		for _, v := range values {
+			if i := invalidHeaderByte(v); i >= 0 {
+				return &RequestError{Field: name, Reason: fmt.Sprintf("has a non-ASCII or control character at offset %d", i)}
+			}
+		}
+	}
+	return nil
+}
+
+// validatePayload checks, for a request signed with its body rather than
+// presigned, that req.ContentLength, which is sent and signed as
+// Content-Length, agrees with a body of known length, and that an
+// X-Amz-Content-Sha256 header is payloadHash. A nil Body is taken to be
+// attached after signing.
+func validatePayload(req *http.Request, payloadHash string, profile Profile) error {
+	if length := req.ContentLength; length > 0 {
+		if req.Body == http.NoBody {
+			return &RequestError{Field: "ContentLength", Reason: fmt.Sprintf("is %d but the request has no body", length)}
+		}
+		if n, ok := bodyLength(req); ok && n != length {
+			return &RequestError{Field: "ContentLength", Reason: fmt.Sprintf("is %d but the body is %d bytes", length, n)}
+		}
+	}
+
+	shaKey := profile.ContentSHAKey()
+	if v := req.Header.Get(shaKey); v != "" && v != payloadHash {
+		return &RequestError{Field: shaKey, Reason: fmt.Sprintf("%q does not match payload hash %q", v, payloadHash)}
+	}
+	return nil
+}
+
+// bodyLength returns the length of the body of req if it is known without
+// reading it through: from a Body with a Len method, or from a copy of the
+// body by GetBody that writes itself out in one call, as those
+// http.NewRequest makes for *bytes.Buffer, *bytes.Reader and
+// *strings.Reader bodies do.
+func bodyLength(req *http.Request) (int64, bool) {
+	if body, ok := req.Body.(interface{ Len() int }); ok {
+		return int64(body.Len()), true
+	}
+	if req.GetBody == nil {
+		return 0, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return 0, false
+	}
+	defer body.Close()
+	if w, ok := body.(io.WriterTo); ok {
+		var counter singleWrite
+		if n, err := w.WriteTo(&counter); err == nil {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// errPartialWrite stops a body that is not written out in one call.
+var errPartialWrite = errors.New("body written in parts")
+
+// singleWrite discards one write and fails any further ones, so that
+// bodyLength stops streamed bodies after their first chunk.
+type singleWrite struct {
+	done bool
+}
+
+func (w *singleWrite) Write(p []byte) (int, error) {
+	if w.done {
+		return 0, errPartialWrite
+	}
+	w.done = true
+	return len(p), nil
+}
+
+func (w *singleWrite) WriteString(s string) (int, error) {
+	if w.done {
+		return 0, errPartialWrite
+	}
+	w.done = true
+	return len(s), nil
+}
+
+// stripDefaultPort removes the port from host if it is the default port
+// of scheme.
+func stripDefaultPort(scheme, host string) string {
+	if port := PortOnly(host); port != "" && IsDefaultPort(scheme, port) {
+		return StripPort(host)
+	}
+	return host
+}
+
+// invalidHeaderByte returns the offset of the first byte of a header
+// value that is not printable ASCII or a tab, or -1.
+func invalidHeaderByte(value string) int {
+	for i := 0; i < len(value); i++ {
+		if c := value[i]; (c < ' ' && c != '\t') || c > '~' {
+			return i
+		}
+	}
+	return -1
+}
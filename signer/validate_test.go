@@ -0,0 +1,174 @@
+package signer
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateRequest(t *testing.T) {
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPut, "https://bucket.example.com/key", strings.NewReader("data"))
+		return req
+	}
+
+	tests := []struct {
+		name  string
+		req   func() *http.Request
+		field string
+
+		// signOnly is set for checks of the body and payload hash, which
+		// PresignHTTP skips.
+		signOnly bool
+	}{
+		{"nil request", func() *http.Request { return nil }, "Request", false},
+		{"nil URL", func() *http.Request {
+			req := newRequest()
+			req.URL = nil
+			return req
+		}, "URL", false},
+		{"nil header", func() *http.Request {
+			req := newRequest()
+			req.Header = nil
+			return req
+		}, "Header", false},
+		{"empty host", func() *http.Request {
+			req := newRequest()
+			req.URL = &url.URL{Scheme: "https", Path: "/key"}
+			req.Host = ""
+			return req
+		}, "Host", false},
+		{"conflicting host header", func() *http.Request {
+			req := newRequest()
+			req.Header.Set("Host", "other.example.com")
+			return req
+		}, "Host", false},
+		{"host header with another port", func() *http.Request {
+			req := newRequest()
+			req.Header.Set("Host", "bucket.example.com:8443")
+			return req
+		}, "Host", false},
+		{"content length without body", func() *http.Request {
+			req := newRequest()
+			req.Body = http.NoBody
+			return req
+		}, "ContentLength", true},
+		{"negative content length", func() *http.Request {
+			req := newRequest()
+			req.ContentLength = -2
+			return req
+		}, "ContentLength", false},
+		{"conflicting content length header", func() *http.Request {
+			req := newRequest()
+			req.Header.Set("Content-Length", "5")
+			return req
+		}, "Content-Length", false},
+		{"non-ASCII header value", func() *http.Request {
+			req := newRequest()
+			req.Header.Set("X-Amz-Meta-Name", "café")
+			return req
+		}, "X-Amz-Meta-Name", false},
+		{"control character in header value", func() *http.Request {
+			req := newRequest()
+			req.Header.Set("X-Amz-Meta-Name", "a\nb")
+			return req
+		}, "X-Amz-Meta-Name", false},
+		{"content hash mismatch", func() *http.Request {
+			req := newRequest()
+			req.Header.Set(ContentSHAKey, UnsignedPayload)
+			return req
+		}, ContentSHAKey, true},
+		{"content length disagrees with body", func() *http.Request {
+			req := newRequest()
+			req.ContentLength = 99
+			return req
+		}, "ContentLength", true},
+		{"content length disagrees with buffer", func() *http.Request {
+			req, _ := http.NewRequest(http.MethodPut, "https://bucket.example.com/key", bytes.NewBufferString("data"))
+			req.ContentLength = 3
+			return req
+		}, "ContentLength", true},
+		{"content length disagrees with Len", func() *http.Request {
+			req := newRequest()
+			req.Body = lenBody{strings.NewReader("data")}
+			req.GetBody = nil
+			req.ContentLength = 5
+			return req
+		}, "ContentLength", true},
+	}
+
+	s, _ := NewSigner(testConfig)
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.SignHTTP(tt.req(), EmptyStringSHA256, signingTime)
+			var reqErr *RequestError
+			if !errors.As(err, &reqErr) || reqErr.Field != tt.field {
+				t.Fatalf("expected *RequestError for %s, got %v", tt.field, err)
+			}
+			if !errors.Is(err, ErrInvalidRequest) || ErrorCategoryOf(err) != ErrorCategoryRequest {
+				t.Errorf("expected %v to match ErrInvalidRequest", err)
+			}
+			_, _, err = s.PresignHTTP(tt.req(), EmptyStringSHA256, signingTime)
+			if tt.signOnly && err != nil {
+				t.Errorf("PresignHTTP failed: %v", err)
+			} else if !tt.signOnly && !errors.Is(err, ErrInvalidRequest) {
+				t.Errorf("PresignHTTP: expected ErrInvalidRequest, got %v", err)
+			}
+		})
+	}
+}
+
+// lenBody is a request body that reports its length.
+type lenBody struct {
+	*strings.Reader
+}
+
+func (lenBody) Close() error { return nil }
+
+func TestValidateRequestAccepts(t *testing.T) {
+	s, _ := NewSigner(testConfig)
+	signingTime := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	req, _ := http.NewRequest(http.MethodPut, "https://bucket.example.com/key", strings.NewReader("data"))
+	req.Header.Set("Host", "BUCKET.example.com")
+	req.Header.Set("Content-Length", "4")
+	req.Header.Set(ContentSHAKey, EmptyStringSHA256)
+	req.Header.Set("X-Amz-Meta-Note", "tab\tseparated")
+	if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+		t.Errorf("SignHTTP failed: %v", err)
+	}
+
+	// A Host header is the request host whether or not either has the
+	// default port.
+	for _, tt := range [][2]string{
+		{"https://bucket.example.com:443/key", "bucket.example.com"},
+		{"https://bucket.example.com/key", "bucket.example.com:443"},
+		{"http://bucket.example.com:80/key", "BUCKET.example.com"},
+	} {
+		req, _ = http.NewRequest(http.MethodGet, tt[0], nil)
+		req.Header.Set("Host", tt[1])
+		if err := s.SignHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+			t.Errorf("SignHTTP failed for %s with Host %s: %v", tt[0], tt[1], err)
+		}
+	}
+
+	// A nil body is taken to be attached after signing.
+	req, _ = http.NewRequest(http.MethodPut, "https://bucket.example.com/key", nil)
+	req.ContentLength = 1024
+	if err := s.SignHTTP(req, UnsignedPayload, signingTime); err != nil {
+		t.Errorf("SignHTTP failed for body attached later: %v", err)
+	}
+
+	// A body of unknown length is signed without Content-Length.
+	req, _ = http.NewRequest(http.MethodPut, "https://bucket.example.com/key", nil)
+	req.Body = http.NoBody
+	req.ContentLength = -1
+	if err := s.SignHTTP(req, UnsignedPayload, signingTime); err != nil {
+		t.Errorf("SignHTTP failed for unknown length: %v", err)
+	}
+}
@@ -0,0 +1,282 @@
+package signer
+
+import (
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxClockSkew is the largest difference allowed between the signing
+	// time of a request and the time it is verified.
+	MaxClockSkew = 15 * time.Minute
+
+	// MaxPresignExpiry is the longest X-Amz-Expires accepted for a
+	// presigned request.
+	MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// allHeaders is a Rule that accepts every header name. The verifier has
+// already reduced the headers to those named in SignedHeaders.
+var allHeaders = ExcludeList{Rules{}}
+
+// Verifier verifies requests signed with SigV4, as the receiving service
+// would, using the same canonicalization as Signer. Thread safety follows
+// Config.ThreadSafety as for Signer.
+type Verifier struct {
+	config       Config
+	keyDerivator KeyDeriver
+}
+
+// NewVerifier creates a Verifier for requests signed with the credentials,
+// region and service in config.
+func NewVerifier(config Config) (*Verifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Verifier{
+		config:       config,
+		keyDerivator: newKeyDerivator(config),
+	}, nil
+}
+
+// String formats the Verifier with the secrets of its Config redacted.
+func (v *Verifier) String() string {
+	return "Verifier{" + v.config.String() + "}"
+}
+
+// GoString formats the Verifier for %#v as String does.
+func (v *Verifier) GoString() string {
+	return "&signer." + v.String()
+}
+
+// IsPresigned reports whether req carries a query string signature
+// rather than an Authorization header, under AWSProfile or GCSProfile.
+func IsPresigned(req *http.Request) bool {
+	query := req.URL.Query()
+	return query.Has(AWSProfile.SignatureKey()) || query.Has(GCSProfile.SignatureKey())
+}
+
+// VerifyHTTP verifies the Authorization header of a request signed as by
+// SignHTTP. The payloadHash is the hash the signer used for the body,
+// typically the X-Amz-Content-Sha256 header for S3. The signing time must
+// be within MaxClockSkew of now, or a *ClockSkewError is returned. A
+// signature that does not match fails with ErrSignatureMismatch.
+func (v *Verifier) VerifyHTTP(req *http.Request, payloadHash string, now time.Time) (err error) {
+	var ev *signingEvent
+	if o := v.config.Observer; o != nil {
+		defer observeSince(req.Context(), o, OperationVerify, time.Now(), &err)
+	}
+	if ctx := req.Context(); debugEnabled(ctx, v.config.Logger) {
+		ev = newVerifyEvent(req, payloadHash, false)
+		defer func() { ev.log(ctx, v.config.Logger, "verified request", err) }()
+	}
+
+	auth := req.Header.Get(AuthorizationHeader)
+	if auth == "" {
+		return ErrMissingAuthorization
+	}
+
+	profile := v.config.Profile
+	algorithm, fields, ok := strings.Cut(auth, " ")
+	if !ok || algorithm != profile.Algorithm {
+		return fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+	params := make(map[string]string)
+	for _, f := range strings.Split(fields, ",") {
+		k, val, _ := strings.Cut(strings.TrimSpace(f), "=")
+		params[k] = val
+	}
+
+	signingTime, err := parseSigningTime(profile.DateKey(), req.Header.Get(profile.DateKey()))
+	if err != nil {
+		return err
+	}
+	if skew := now.Sub(signingTime.Time); skew > MaxClockSkew || skew < -MaxClockSkew {
+		return &ClockSkewError{SigningTime: signingTime.Time, Now: now}
+	}
+
+	query := req.URL.Query()
+	return v.verify(req, query, params["Credential"], params["SignedHeaders"], params["Signature"], payloadHash, signingTime, ev)
+}
+
+// VerifyPresignedHTTP verifies the query string signature of a request
+// presigned as by PresignHTTP. The payloadHash is the hash the signer
+// used, typically UnsignedPayload. The request must not be used before its
+// signing time, less MaxClockSkew, which fails with a *ClockSkewError, or
+// after X-Amz-Expires has elapsed, which fails with ErrPresignExpired.
+func (v *Verifier) VerifyPresignedHTTP(req *http.Request, payloadHash string, now time.Time) (err error) {
+	var ev *signingEvent
+	if o := v.config.Observer; o != nil {
+		defer observeSince(req.Context(), o, OperationVerifyPresigned, time.Now(), &err)
+	}
+	if ctx := req.Context(); debugEnabled(ctx, v.config.Logger) {
+		ev = newVerifyEvent(req, payloadHash, true)
+		defer func() { ev.log(ctx, v.config.Logger, "verified presigned request", err) }()
+	}
+
+	profile := v.config.Profile
+	query := req.URL.Query()
+
+	if algorithm := query.Get(profile.AlgorithmKey()); algorithm != profile.Algorithm {
+		return fmt.Errorf("%w %q", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	signingTime, err := parseSigningTime(profile.DateKey(), query.Get(profile.DateKey()))
+	if err != nil {
+		return err
+	}
+
+	expires, err := strconv.Atoi(query.Get(profile.ExpiresKey()))
+	if err != nil || expires <= 0 || time.Duration(expires)*time.Second > MaxPresignExpiry {
+		return fmt.Errorf("%w %s=%q", ErrInvalidPresignExpiry, profile.ExpiresKey(), query.Get(profile.ExpiresKey()))
+	}
+	if now.Before(signingTime.Time.Add(-MaxClockSkew)) {
+		return &ClockSkewError{SigningTime: signingTime.Time, Now: now}
+	}
+	if now.After(signingTime.Time.Add(time.Duration(expires) * time.Second)) {
+		return ErrPresignExpired
+	}
+
+	signature := query.Get(profile.SignatureKey())
+	query.Del(profile.SignatureKey())
+	return v.verify(req, query, query.Get(profile.CredentialKey()), query.Get(profile.SignedHeadersKey()), signature, payloadHash, signingTime, ev)
+}
+
+// verify rebuilds the canonical request from the signed headers and
+// canonical query and compares the resulting signature. If ev is not
+// nil, the credential, signed headers and key cache use are recorded in
+// it for logging.
+func (v *Verifier) verify(req *http.Request, query url.Values, credentialStr, signedHeadersStr, signature, payloadHash string, signingTime SigningTime, ev *signingEvent) error {
+	if ev != nil {
+		ev.SignedHeaders = signedHeadersStr
+		ev.Signature = signature
+	}
+	if credentialStr == "" || signedHeadersStr == "" || signature == "" {
+		return ErrMissingSignature
+	}
+	if payloadHash == "" {
+		return ErrMissingPayloadHash
+	}
+
+	cred, err := parseCredential(credentialStr)
+	if err != nil {
+		return err
+	}
+	if ev != nil {
+		ev.AccessKeyID = cred.AccessKeyID
+		ev.Scope = strings.Join([]string{cred.Date, cred.Region, cred.Service, cred.Terminator}, "/")
+	}
+	if err := cred.check(v.config, signingTime); err != nil {
+		return err
+	}
+	if v.config.SessionToken != "" {
+		token := query.Get(v.config.Profile.SecurityTokenKey())
+		if token == "" {
+			token = req.Header.Get(v.config.Profile.SecurityTokenKey())
+		}
+		if token != v.config.SessionToken {
+			return ErrSecurityTokenMismatch
+		}
+	}
+
+	host := GetHost(req)
+	header := make(http.Header)
+	var length int64
+	for _, name := range strings.Split(signedHeadersStr, ";") {
+		switch name {
+		case "host":
+		case "content-length":
+			length = req.ContentLength
+		default:
+			if values := req.Header.Values(name); len(values) > 0 {
+				header[name] = values
+			}
+		}
+	}
+
+	_, rebuiltHeadersStr, canonicalHeaderStr := BuildCanonicalHeaders(
+		host,
+		allHeaders,
+		header,
+		length,
+	)
+	if rebuiltHeadersStr != signedHeadersStr {
+		return fmt.Errorf("%w: %q, %q", ErrSignedHeadersMismatch, signedHeadersStr, rebuiltHeadersStr)
+	}
+
+	for key := range query {
+		sort.Strings(query[key])
+	}
+	rawQuery := strings.Replace(query.Encode(), "+", "%20", -1)
+
+	canonicalString := BuildCanonicalString(
+		req.Method,
+		GetURIPath(req.URL),
+		rawQuery,
+		signedHeadersStr,
+		canonicalHeaderStr,
+		payloadHash,
+	)
+
+	strToSign := BuildStringToSign(
+		v.config.Profile.Algorithm,
+		signingTime.TimeFormat(),
+		v.config.Profile.CredentialScope(signingTime, v.config.Region, v.config.Service),
+		canonicalString,
+	)
+
+	key, cached, err := deriveSigningKey(
+		req.Context(),
+		v.config.Observer,
+		v.keyDerivator,
+		v.config.AccessKeyID,
+		v.config.SecretAccessKey,
+		v.config.Service,
+		v.config.Region,
+		signingTime,
+	)
+	if err != nil {
+		return err
+	}
+	if ev != nil {
+		ev.CachedKey = cached
+	}
+
+	expected, _ := hex.DecodeString(BuildSignature(key, strToSign))
+	actual, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, actual) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
+
+// newVerifyEvent starts the debug log event of a verification.
+func newVerifyEvent(req *http.Request, payloadHash string, presigned bool) *signingEvent {
+	return &signingEvent{
+		Method:      req.Method,
+		Host:        GetHost(req),
+		PayloadHash: payloadHash,
+		Presigned:   presigned,
+	}
+}
+
+// parseSigningTime parses the value of the date parameter key, such as
+// X-Amz-Date.
+func parseSigningTime(key, value string) (SigningTime, error) {
+	if value == "" {
+		return SigningTime{}, fmt.Errorf("%w: %s is required", ErrInvalidSigningTime, key)
+	}
+	t, err := time.Parse(TimeFormat, value)
+	if err != nil {
+		return SigningTime{}, fmt.Errorf("%w %s=%q", ErrInvalidSigningTime, key, value)
+	}
+	return NewSigningTime(t), nil
+}
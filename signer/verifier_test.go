@@ -0,0 +1,227 @@
+package signer
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var verifyTime = time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC)
+
+// serverSide returns req as a server would see it after it was sent.
+func serverSide(t *testing.T, req *http.Request) *http.Request {
+	t.Helper()
+
+	u, err := url.Parse(req.URL.String())
+	if err != nil {
+		t.Fatalf("failed to parse URL: %v", err)
+	}
+	received := &http.Request{
+		Method:        req.Method,
+		URL:           &url.URL{Path: u.Path, RawPath: u.RawPath, RawQuery: u.RawQuery},
+		Host:          GetHost(req),
+		Header:        req.Header.Clone(),
+		ContentLength: req.ContentLength,
+	}
+	return received
+}
+
+func TestVerifyHTTP(t *testing.T) {
+	signer, _ := NewSigner(testConfig)
+	verifier, err := NewVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	req, payloadHash := buildTestRequest("PUT", "https://example.com:443/bucket/a%20key?b=2&a=1", "data")
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("X-Amz-Meta-Owner", "ingest")
+	req.Header.Set("User-Agent", "test")
+	if err := signer.SignHTTP(req, payloadHash, verifyTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	received := serverSide(t, req)
+	if err := verifier.VerifyHTTP(received, payloadHash, verifyTime.Add(time.Minute)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Unsigned headers may be changed in transit.
+	received.Header.Set("User-Agent", "proxy")
+	if err := verifier.VerifyHTTP(received, payloadHash, verifyTime); err != nil {
+		t.Errorf("expected no error for unsigned header change, got %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *http.Request)
+		now    time.Time
+		hash   string
+	}{
+		{
+			name:   "signed header changed",
+			modify: func(r *http.Request) { r.Header.Set("X-Amz-Meta-Owner", "other") },
+		},
+		{
+			name:   "signed header removed",
+			modify: func(r *http.Request) { r.Header.Del("Content-Type") },
+		},
+		{
+			name:   "query changed",
+			modify: func(r *http.Request) { r.URL.RawQuery = "a=1&b=3" },
+		},
+		{
+			name:   "path changed",
+			modify: func(r *http.Request) { r.URL.Path = "/bucket/other" },
+		},
+		{
+			name:   "method changed",
+			modify: func(r *http.Request) { r.Method = "DELETE" },
+		},
+		{
+			name:   "payload changed",
+			modify: func(r *http.Request) {},
+			hash:   EmptyStringSHA256,
+		},
+		{
+			name:   "clock skew",
+			modify: func(r *http.Request) {},
+			now:    verifyTime.Add(time.Hour),
+		},
+		{
+			name:   "missing authorization",
+			modify: func(r *http.Request) { r.Header.Del(AuthorizationHeader) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := serverSide(t, req)
+			tt.modify(r)
+			now, hash := tt.now, tt.hash
+			if now.IsZero() {
+				now = verifyTime
+			}
+			if hash == "" {
+				hash = payloadHash
+			}
+			if err := verifier.VerifyHTTP(r, hash, now); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestVerifyHTTPWrongCredentials(t *testing.T) {
+	signer, _ := NewSigner(testConfig)
+
+	req, payloadHash := buildTestRequest("GET", "https://example.com/bucket/key", "")
+	if err := signer.SignHTTP(req, payloadHash, verifyTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	for _, modify := range []func(c *Config){
+		func(c *Config) { c.SecretAccessKey = "OTHER" },
+		func(c *Config) { c.AccessKeyID = "OTHER" },
+		func(c *Config) { c.Region = "eu-west-1" },
+		func(c *Config) { c.Service = "sts" },
+	} {
+		config := testConfig
+		modify(&config)
+		verifier, _ := NewVerifier(config)
+		if err := verifier.VerifyHTTP(serverSide(t, req), payloadHash, verifyTime); err == nil {
+			t.Errorf("expected error for config %+v", config)
+		}
+	}
+}
+
+func TestVerifyPresignedHTTP(t *testing.T) {
+	signer, _ := NewSigner(testConfig)
+	verifier, err := NewVerifier(testConfig)
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	req, _ := buildTestRequest("GET", "https://example.com/bucket/key?X-Amz-Expires=300&versionId=1", "")
+	req.Header.Set("X-Amz-Meta-Hoisted", "yes")
+	req.Header.Set("Content-Type", "text/plain")
+
+	signedURL, signedHeaders, err := signer.PresignHTTP(req, UnsignedPayload, verifyTime)
+	if err != nil {
+		t.Fatalf("failed to presign: %v", err)
+	}
+
+	received, _ := http.NewRequest("GET", signedURL, nil)
+	received.Header = signedHeaders
+	received.Header.Del("Host")
+	received = serverSide(t, received)
+
+	if !IsPresigned(received) {
+		t.Fatal("expected presigned request")
+	}
+	if err := verifier.VerifyPresignedHTTP(received, UnsignedPayload, verifyTime.Add(time.Minute)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if err := verifier.VerifyPresignedHTTP(received, UnsignedPayload, verifyTime.Add(301*time.Second)); err == nil {
+		t.Error("expected error for expired request")
+	}
+
+	tampered := serverSide(t, received)
+	q := tampered.URL.Query()
+	q.Set("versionId", "2")
+	tampered.URL.RawQuery = q.Encode()
+	if err := verifier.VerifyPresignedHTTP(tampered, UnsignedPayload, verifyTime); err == nil {
+		t.Error("expected error for tampered query")
+	}
+
+	tampered = serverSide(t, received)
+	tampered.Header.Set("Content-Type", "text/html")
+	if err := verifier.VerifyPresignedHTTP(tampered, UnsignedPayload, verifyTime); err == nil {
+		t.Error("expected error for tampered signed header")
+	}
+}
+
+func TestVerifySessionToken(t *testing.T) {
+	config := testConfig
+	config.SessionToken = "TOKEN"
+	signer, _ := NewSigner(config)
+	verifier, _ := NewVerifier(config)
+
+	req, payloadHash := buildTestRequest("GET", "https://example.com/bucket/key", "")
+	if err := signer.SignHTTP(req, payloadHash, verifyTime); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	if req.Header.Get(AmzSecurityTokenKey) != "TOKEN" {
+		t.Errorf("expected security token header, got %q", req.Header.Get(AmzSecurityTokenKey))
+	}
+	if !strings.Contains(req.Header.Get(AuthorizationHeader), "x-amz-security-token") {
+		t.Error("expected security token to be signed")
+	}
+	received := serverSide(t, req)
+	if err := verifier.VerifyHTTP(received, payloadHash, verifyTime); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	req, _ = buildTestRequest("GET", "https://example.com/bucket/key?X-Amz-Expires=60", "")
+	signedURL, _, err := signer.PresignHTTP(req, UnsignedPayload, verifyTime)
+	if err != nil {
+		t.Fatalf("failed to presign: %v", err)
+	}
+	presigned, _ := http.NewRequest("GET", signedURL, nil)
+	if presigned.URL.Query().Get(AmzSecurityTokenKey) != "TOKEN" {
+		t.Errorf("expected security token query parameter in %s", signedURL)
+	}
+	if err := verifier.VerifyPresignedHTTP(serverSide(t, presigned), UnsignedPayload, verifyTime); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	// A verifier expecting another token rejects the request.
+	config.SessionToken = "OTHER"
+	other, _ := NewVerifier(config)
+	if err := other.VerifyHTTP(received, payloadHash, verifyTime); err == nil {
+		t.Error("expected error for mismatched security token")
+	}
+}
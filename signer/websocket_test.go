@@ -0,0 +1,102 @@
+package signer
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPresignWebSocket(t *testing.T) {
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "SECRET",
+		SessionToken:    "TOKEN/+=",
+		Service:         "iotdevicegateway",
+	}
+	signer, _ := NewSigner(config)
+	signingTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	signedURL, err := signer.PresignWebSocket("wss://abc-ats.iot.us-east-1.amazonaws.com:443/mqtt",
+		WebSocketPresignOptions{Expires: time.Hour, TokenAfterSignature: true}, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !strings.HasPrefix(signedURL, "wss://abc-ats.iot.us-east-1.amazonaws.com/mqtt?") {
+		t.Errorf("expected default port to be removed, got %s", signedURL)
+	}
+	signature := strings.Index(signedURL, "&"+AmzSignatureKey+"=")
+	token := strings.Index(signedURL, "&"+AmzSecurityTokenKey+"=TOKEN%2F%2B%3D")
+	if signature < 0 || token < signature {
+		t.Errorf("expected security token after the signature, got %s", signedURL)
+	}
+
+	// The token is not signed, so the URL verifies without it.
+	u, _ := url.Parse(signedURL[:token])
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	config.SessionToken = ""
+	verifier, _ := NewVerifier(config)
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime); err != nil {
+		t.Errorf("expected URL to verify, got %v", err)
+	}
+}
+
+func TestPresignWebSocketSignedToken(t *testing.T) {
+	config := testConfig
+	config.SessionToken = "TOKEN"
+	config.Service = "transcribe"
+	signer, _ := NewSigner(config)
+	signingTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	signedURL, err := signer.PresignWebSocket("wss://transcribestreaming.us-east-1.amazonaws.com:8443/stream-transcription-websocket?language-code=en-US",
+		WebSocketPresignOptions{Expires: 5 * time.Minute}, signingTime)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	u, _ := url.Parse(signedURL)
+	if u.Host != "transcribestreaming.us-east-1.amazonaws.com:8443" || u.Query().Get(AmzExpiresKey) != "300" {
+		t.Errorf("unexpected URL %s", signedURL)
+	}
+	req := &http.Request{Method: "GET", URL: u, Host: u.Host, Header: http.Header{}}
+	verifier, _ := NewVerifier(config)
+	if err := verifier.VerifyPresignedHTTP(req, EmptyStringSHA256, signingTime.Add(time.Minute)); err != nil {
+		t.Errorf("expected URL to verify, got %v", err)
+	}
+}
+
+func TestPresignWebSocketErrors(t *testing.T) {
+	signer, _ := NewSigner(testConfig)
+
+	tests := []struct {
+		name string
+		url  string
+		opts WebSocketPresignOptions
+		err  error
+	}{
+		{name: "https scheme", url: "https://example.com/mqtt"},
+		{name: "invalid URL", url: "wss://example.com/%zz"},
+		{name: "expiry too long", url: "wss://example.com/mqtt", opts: WebSocketPresignOptions{Expires: 8 * 24 * time.Hour}, err: ErrInvalidPresignExpiry},
+		{name: "sub-second expiry", url: "wss://example.com/mqtt", opts: WebSocketPresignOptions{Expires: 500 * time.Millisecond}, err: ErrInvalidPresignExpiry},
+		{name: "empty host", url: "wss:///mqtt", err: ErrInvalidRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := signer.PresignWebSocket(tt.url, tt.opts, time.Now())
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("expected %v, got %v", tt.err, err)
+			}
+		})
+	}
+
+	var reqErr *RequestError
+	if _, err := signer.PresignWebSocket("wss:///mqtt", WebSocketPresignOptions{}, time.Now()); !errors.As(err, &reqErr) || reqErr.Field != "Host" {
+		t.Errorf("expected *RequestError for Host, got %v", err)
+	}
+}